@@ -0,0 +1,45 @@
+package main
+
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/config"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/pkg/clock"
+	"avitotech/pkg/imcache"
+	"context"
+	"errors"
+	"log/slog"
+	"time"
+)
+
+const adminUsage = "usage: api admin grant|revoke <username>"
+
+// runAdmin executes the admin subcommand granting or revoking the admin role.
+func runAdmin(cfg *config.Config, args []string) error {
+	if len(args) != 2 || (args[0] != "grant" && args[0] != "revoke") {
+		return errors.New(adminUsage)
+	}
+	db, err := database.Open(context.Background(), cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	cache := imcache.NewInMemoryCache(time.Minute)
+	defer cache.Close()
+	store := database.New(db, cache, cfg, clock.NewRealClock(), slog.Default())
+
+	isAdmin := args[0] == "grant"
+	if err := store.SetUserAdmin(args[1], isAdmin); err != nil {
+		return err
+	}
+	action := entities.AuditAdminGrant
+	if !isAdmin {
+		action = entities.AuditAdminRevoke
+	}
+	// The command runs on the server with database access, so it is recorded as the system.
+	audit.NewRecorder(store, slog.Default()).Record(context.Background(), action, 0, args[1], audit.Details{"source": "cli"})
+	slog.Info("Admin role updated", "username", args[1], "is_admin", isAdmin)
+	return nil
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"avitotech/internal/config"
+	"avitotech/internal/database"
+	"avitotech/internal/outbox"
+	"avitotech/internal/scheduler"
+	"avitotech/internal/server"
+	"avitotech/internal/webhooks"
+	"avitotech/pkg/clock"
+	"avitotech/pkg/imcache"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// App is the composition root wiring the configuration, database, HTTP server
+// and background workers together.
+type App struct {
+	cfg     *config.Config
+	logger  *slog.Logger
+	server  *http.Server
+	workers []func(ctx context.Context)
+	closers []func() error
+}
+
+// NewApp opens the database, prepares the schema and builds the HTTP server and workers.
+func NewApp(ctx context.Context, cfg *config.Config, logger *slog.Logger) (_ *App, err error) {
+	app := &App{cfg: cfg, logger: logger}
+	defer func() {
+		if err != nil {
+			app.Close()
+		}
+	}()
+
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	app.closers = append(app.closers, func() error {
+		logger.Info("Disconnected from", "database", cfg.Database.Name)
+		return db.Close()
+	})
+	if err := prepareSchema(ctx, cfg, db); err != nil {
+		return nil, fmt.Errorf("database schema: %w", err)
+	}
+	replica, err := database.OpenReplica(ctx, cfg.Database)
+	if err != nil {
+		return nil, err
+	}
+	if replica != nil {
+		app.closers = append(app.closers, replica.Close)
+	}
+
+	clk := clock.NewRealClock()
+	cache := imcache.NewInMemoryCache(5 * time.Minute)
+	app.closers = append(app.closers, func() error {
+		cache.Close()
+		return nil
+	})
+
+	// One store serves requests and workers, so they share the cache and read-your-writes pins.
+	var storeOptions []database.Option
+	if replica != nil {
+		storeOptions = append(storeOptions, database.WithReplica(replica, cfg.Database.ReadYourWritesWindow))
+	}
+	store := database.New(db, cache, cfg, clk, logger, storeOptions...)
+
+	handler, cleanup := server.NewServer(cfg, server.Deps{
+		Store:  store,
+		Cache:  cache,
+		Clock:  clk,
+		Logger: logger,
+	})
+	app.closers = append(app.closers, func() error {
+		cleanup()
+		return nil
+	})
+	app.server = &http.Server{
+		Addr:         fmt.Sprintf(":%d", cfg.Server.Port),
+		Handler:      handler,
+		IdleTimeout:  cfg.Server.IdleTimeout,
+		ReadTimeout:  cfg.Server.ReadTimeout,
+		WriteTimeout: cfg.Server.WriteTimeout,
+	}
+
+	if err := app.addOutboxDispatcher(store, clk); err != nil {
+		return nil, err
+	}
+	if cfg.Scheduler.Enabled {
+		app.addScheduler(store, clk)
+	}
+	return app, nil
+}
+
+// addScheduler registers the scheduler executing scheduled transfers.
+func (a *App) addScheduler(store database.Service, clk clock.Clock) {
+	s := scheduler.NewScheduler(store, clk, a.logger, scheduler.Options{
+		PollInterval: a.cfg.Scheduler.PollInterval,
+		BatchSize:    a.cfg.Scheduler.BatchSize,
+		LockKey:      int64(a.cfg.Scheduler.LockKey),
+		MaxFailures:  a.cfg.Scheduler.MaxFailures,
+	})
+	a.workers = append(a.workers, s.Run)
+}
+
+// addOutboxDispatcher registers the outbox dispatcher delivering events to
+// webhook subscriptions and to the configured sinks.
+func (a *App) addOutboxDispatcher(store database.Service, clk clock.Clock) error {
+	notifier := webhooks.NewNotifier(store, &http.Client{Timeout: a.cfg.Webhooks.Timeout}, clk, a.logger, a.cfg.Webhooks.MaxConsecutiveFailures)
+	sinks := []outbox.Sink{notifier}
+	if a.cfg.Outbox.WebhookURL != "" {
+		client := &http.Client{Timeout: a.cfg.Outbox.WebhookTimeout}
+		sinks = append(sinks, outbox.NewHTTPSink(a.cfg.Outbox.WebhookURL, client))
+	}
+	if a.cfg.Outbox.File != "" {
+		fileSink, err := outbox.NewFileSink(a.cfg.Outbox.File)
+		if err != nil {
+			return fmt.Errorf("outbox file sink: %w", err)
+		}
+		a.closers = append(a.closers, fileSink.Close)
+		sinks = append(sinks, fileSink)
+	}
+	dispatcher := outbox.NewDispatcher(store, sinks, clk, a.logger, outbox.Options{
+		PollInterval: a.cfg.Outbox.PollInterval,
+		BatchSize:    a.cfg.Outbox.BatchSize,
+		Lease:        a.cfg.Outbox.Lease,
+		BaseBackoff:  a.cfg.Outbox.BaseBackoff,
+		MaxBackoff:   a.cfg.Outbox.MaxBackoff,
+		MaxAttempts:  a.cfg.Outbox.MaxAttempts,
+	})
+	a.workers = append(a.workers, dispatcher.Run)
+	return nil
+}
+
+// Run serves HTTP and runs the background workers until ctx is cancelled,
+// then shuts everything down gracefully.
+func (a *App) Run(ctx context.Context) error {
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	defer stopWorkers()
+	var wg sync.WaitGroup
+	for _, worker := range a.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			worker(workersCtx)
+		}()
+	}
+	defer wg.Wait()
+
+	errCh := make(chan error, 1)
+	go func() {
+		a.logger.Info("Server started", "addr", a.server.Addr)
+		errCh <- a.server.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		stopWorkers()
+		if !errors.Is(err, http.ErrServerClosed) {
+			return fmt.Errorf("http server error: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+	}
+
+	a.logger.Info("shutting down gracefully, press Ctrl+C again to force")
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), a.cfg.Server.ShutdownTimeout)
+	defer cancel()
+	if err := a.server.Shutdown(shutdownCtx); err != nil {
+		a.logger.Info("Server forced to shutdown with", "error", err)
+	}
+
+	a.logger.Info("Server exiting")
+	return nil
+}
+
+// Close releases the resources owned by the application in reverse order of acquisition.
+func (a *App) Close() error {
+	var errs []error
+	for i := len(a.closers) - 1; i >= 0; i-- {
+		errs = append(errs, a.closers[i]())
+	}
+	a.closers = nil
+	return errors.Join(errs...)
+}
@@ -1,62 +1,67 @@
 package main
 
 import (
-	"avitotech/internal/server"
+	"avitotech/internal/config"
 	"context"
-	"errors"
 	"log"
 	"log/slog"
-	"net/http"
 	"os"
 	"os/signal"
 	"syscall"
-	"time"
 )
 
-func setupLogger() {
+func setupLogger(level string) *slog.Logger {
 	logLevel := new(slog.LevelVar)
 	options := &slog.HandlerOptions{Level: logLevel}
 
-	if os.Getenv("LOG_LEVEL") == "debug" {
+	if level == "debug" {
 		logLevel.Set(slog.LevelDebug)
 		options.AddSource = true
 	}
 	logger := slog.New(slog.NewJSONHandler(os.Stderr, options))
 	slog.SetDefault(logger)
-}
-
-func gracefulShutdown(apiServer *http.Server, done chan bool) {
-	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
-	defer stop()
-
-	<-ctx.Done()
-
-	slog.Info("shutting down gracefully, press Ctrl+C again to force")
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := apiServer.Shutdown(ctx); err != nil {
-		slog.Info("Server forced to shutdown with", "error", err)
-	}
-
-	slog.Info("Server exiting")
-
-	done <- true
+	return logger
 }
 
 func main() {
-	setupLogger()
-	srv := server.NewServer()
+	cfg, err := config.Load()
+	if err != nil {
+		log.Fatalf("config: %s", err)
+	}
+	logger := setupLogger(cfg.LogLevel)
 
-	done := make(chan bool, 1)
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "migrate":
+			if err := runMigrate(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("migrate: %s", err)
+			}
+			return
+		case "admin":
+			if err := runAdmin(cfg, os.Args[2:]); err != nil {
+				log.Fatalf("admin: %s", err)
+			}
+			return
+		}
+	}
 
-	go gracefulShutdown(srv, done)
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	go func() {
+		// Restore default signal handling so a second Ctrl+C forces exit.
+		<-ctx.Done()
+		stop()
+	}()
 
-	err := srv.ListenAndServe()
-	if err != nil && !errors.Is(err, http.ErrServerClosed) {
-		log.Fatalf("http server error: %s", err)
+	app, err := NewApp(ctx, cfg, logger)
+	if err != nil {
+		log.Fatalf("startup: %s", err)
 	}
+	defer app.Close()
 
-	<-done
-	slog.Info("Graceful shutdown complete.")
+	if err := app.Run(ctx); err != nil {
+		logger.Error("Server stopped", "error", err)
+		return
+	}
+	logger.Info("Graceful shutdown complete.")
 }
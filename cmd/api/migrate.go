@@ -0,0 +1,58 @@
+package main
+
+import (
+	"avitotech/internal/config"
+	"avitotech/internal/database"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+)
+
+const migrateUsage = "usage: api migrate up|down|status"
+
+// runMigrate executes the migrate subcommand with the given arguments.
+func runMigrate(cfg *config.Config, args []string) error {
+	if len(args) != 1 {
+		return errors.New(migrateUsage)
+	}
+	ctx := context.Background()
+	db, err := database.Open(ctx, cfg.Database)
+	if err != nil {
+		return err
+	}
+	defer db.Close()
+
+	switch args[0] {
+	case "up":
+		return database.MigrateUp(ctx, db)
+	case "down":
+		return database.MigrateDown(ctx, db)
+	case "status":
+		statuses, err := database.MigrationStatus(ctx, db)
+		if err != nil {
+			return err
+		}
+		for _, status := range statuses {
+			appliedAt := "pending"
+			if !status.AppliedAt.IsZero() {
+				appliedAt = status.AppliedAt.Format("2006-01-02 15:04:05")
+			}
+			fmt.Printf("%-20s %-40s %s\n", appliedAt, status.Source.Path, status.State)
+		}
+		return nil
+	default:
+		return errors.New(migrateUsage)
+	}
+}
+
+// prepareSchema applies pending migrations when MigrateOnStart is set,
+// otherwise it verifies that the schema is up to date.
+func prepareSchema(ctx context.Context, cfg *config.Config, db *sql.DB) error {
+	if cfg.MigrateOnStart {
+		slog.Info("Applying migrations on start")
+		return database.MigrateUp(ctx, db)
+	}
+	return database.CheckSchemaVersion(ctx, db)
+}
@@ -0,0 +1,9 @@
+// Package docs embeds the API description served by the server and used by its contract tests.
+package docs
+
+import _ "embed"
+
+// OpenAPI is the OpenAPI 3 description of the HTTP API.
+//
+//go:embed api.yaml
+var OpenAPI []byte
@@ -0,0 +1,92 @@
+// Package audit records security-relevant actions in the append-only audit log.
+package audit
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/logging"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"strings"
+	"unicode/utf8"
+)
+
+// Details are the action-specific fields of an entry.
+type Details map[string]any
+
+// Meta describes the request an action was made in.
+type Meta struct {
+	IP        string
+	UserAgent string
+	RequestID string
+}
+
+type metaKey struct{}
+
+// WithMeta returns a copy of ctx carrying the request metadata.
+func WithMeta(ctx context.Context, meta Meta) context.Context {
+	return context.WithValue(ctx, metaKey{}, meta)
+}
+
+// MetaFrom returns the request metadata of ctx, empty outside of a request.
+func MetaFrom(ctx context.Context) Meta {
+	meta, _ := ctx.Value(metaKey{}).(Meta)
+	return meta
+}
+
+// Recorder appends actions to the audit log.
+type Recorder interface {
+	// Record appends the action of the actor on the target, actorId is 0 for anonymous requests and the system.
+	Record(ctx context.Context, action string, actorId int, target string, details Details)
+}
+
+type recorder struct {
+	store  database.AuditStore
+	logger *slog.Logger
+}
+
+func NewRecorder(store database.AuditStore, logger *slog.Logger) *recorder {
+	return &recorder{
+		store:  store,
+		logger: logger,
+	}
+}
+
+// Record appends the action of the actor on the target, actorId is 0 for anonymous requests and the system.
+// The action has already happened by the time it is recorded, so failures are logged rather than returned.
+func (r *recorder) Record(ctx context.Context, action string, actorId int, target string, details Details) {
+	meta := MetaFrom(ctx)
+	logger := logging.FromContext(ctx, r.logger)
+	entry := &entities.AuditEntry{
+		Action:    action,
+		ActorID:   actorId,
+		Target:    target,
+		IP:        meta.IP,
+		UserAgent: truncate(meta.UserAgent, 512),
+		RequestID: truncate(meta.RequestID, 128),
+	}
+	if len(details) > 0 {
+		raw, err := json.Marshal(details)
+		if err != nil {
+			logger.Error("Audit details", "action", action, "error", err)
+		}
+		entry.Details = raw
+	}
+	if err := r.store.AppendAuditEntry(entry); err != nil {
+		logger.Error("Audit record", "action", action, "actor", actorId, "target", target, "error", err)
+	}
+}
+
+// truncate cuts s to at most n bytes without splitting a rune and replaces invalid UTF-8,
+// Postgres rejects both and the entry would be lost.
+func truncate(s string, n int) string {
+	s = strings.ToValidUTF8(s, "\uFFFD")
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
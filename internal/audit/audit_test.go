@@ -0,0 +1,100 @@
+package audit
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"context"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+// fakeStore keeps appended entries in memory.
+type fakeStore struct {
+	database.AuditStore
+
+	entries []entities.AuditEntry
+}
+
+func (f *fakeStore) AppendAuditEntry(entry *entities.AuditEntry) error {
+	f.entries = append(f.entries, *entry)
+	return nil
+}
+
+func TestRecord(t *testing.T) {
+	store := &fakeStore{}
+	recorder := NewRecorder(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	ctx := WithMeta(context.Background(), Meta{IP: "10.0.0.1", UserAgent: "curl/8.0", RequestID: "req-1"})
+	recorder.Record(ctx, entities.AuditTransfer, 7, "bob", Details{"amount": 100})
+	recorder.Record(context.Background(), entities.AuditLoginFailed, 0, "alice", nil)
+
+	if len(store.entries) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(store.entries))
+	}
+	entry := store.entries[0]
+	if entry.Action != entities.AuditTransfer || entry.ActorID != 7 || entry.Target != "bob" {
+		t.Errorf("unexpected entry %+v", entry)
+	}
+	if entry.IP != "10.0.0.1" || entry.UserAgent != "curl/8.0" || entry.RequestID != "req-1" {
+		t.Errorf("expected the request metadata to be recorded, got %+v", entry)
+	}
+	if string(entry.Details) != `{"amount":100}` {
+		t.Errorf("expected details %s, got %s", `{"amount":100}`, entry.Details)
+	}
+	if entry := store.entries[1]; entry.IP != "" || entry.Details != nil {
+		t.Errorf("expected an entry without metadata and details, got %+v", entry)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"curl/8.0", 512, "curl/8.0"},
+		{"curl/8.0", 4, "curl"},
+		{"Привет", 12, "Привет"},
+		{"Привет", 5, "Пр"},
+		{"Привет", 1, ""},
+		{"a\xffb", 512, "a\uFFFDb"},
+	}
+	for _, tt := range tests {
+		got := truncate(tt.s, tt.n)
+		if got != tt.want || !utf8.ValidString(got) || len(got) > tt.n {
+			t.Errorf("truncate(%q, %d) = %q, want %q", tt.s, tt.n, got, tt.want)
+		}
+	}
+
+	store := &fakeStore{}
+	recorder := NewRecorder(store, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	userAgent := strings.Repeat("Я", 300) // 600 bytes, the last rune would be split at 512
+	recorder.Record(WithMeta(context.Background(), Meta{UserAgent: userAgent}), entities.AuditLogin, 1, "alice", nil)
+	if got := store.entries[0].UserAgent; got != strings.Repeat("Я", 256) {
+		t.Errorf("expected the user agent to be cut to 256 runes, got %d bytes, valid %v", len(got), utf8.ValidString(got))
+	}
+}
+
+func TestComputeHash(t *testing.T) {
+	entry := entities.AuditEntry{Action: entities.AuditLogin, ActorID: 1, Target: "alice", PrevHash: entities.AuditGenesisHash}
+	hash := entry.ComputeHash()
+	if len(hash) != 64 {
+		t.Fatalf("expected a hex SHA-256 hash, got %q", hash)
+	}
+	if entry.ComputeHash() != hash {
+		t.Errorf("expected the hash to be deterministic")
+	}
+	tampered := entry
+	tampered.ActorID = 2
+	if tampered.ComputeHash() == hash {
+		t.Errorf("expected changing the actor to change the hash")
+	}
+	relinked := entry
+	relinked.PrevHash = hash
+	if relinked.ComputeHash() == hash {
+		t.Errorf("expected the hash to depend on the previous entry")
+	}
+}
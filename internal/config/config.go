@@ -0,0 +1,397 @@
+package config
+
+import (
+	"avitotech/internal/entities"
+	"errors"
+	"fmt"
+	"io/fs"
+	"net/url"
+	"os"
+	"slices"
+	"time"
+
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	defaultPort         = 8080
+	defaultInitialCoins = 1000
+)
+
+var (
+	statementCacheModes = []string{"cache_statement", "cache_describe", "describe_exec", "exec", "simple_protocol"}
+	sslModes            = []string{"disable", "allow", "prefer", "require", "verify-ca", "verify-full"}
+)
+
+// Config is the application configuration.
+type Config struct {
+	Env            string    `yaml:"env"`
+	LogLevel       string    `yaml:"log_level"`
+	MigrateOnStart bool      `yaml:"migrate_on_start"`
+	Server         Server    `yaml:"server"`
+	Database       Database  `yaml:"database"`
+	Auth           Auth      `yaml:"auth"`
+	Wallet         Wallet    `yaml:"wallet"`
+	Shop           Shop      `yaml:"shop"`
+	Outbox         Outbox    `yaml:"outbox"`
+	Webhooks       Webhooks  `yaml:"webhooks"`
+	Scheduler      Scheduler `yaml:"scheduler"`
+}
+
+// Server holds the HTTP server settings.
+type Server struct {
+	Port            int           `yaml:"port"`
+	ReadTimeout     time.Duration `yaml:"read_timeout"`
+	WriteTimeout    time.Duration `yaml:"write_timeout"`
+	IdleTimeout     time.Duration `yaml:"idle_timeout"`
+	ShutdownTimeout time.Duration `yaml:"shutdown_timeout"`
+	CORSOrigins     []string      `yaml:"cors_origins"`
+	// OpenAPIValidation checks requests and responses against docs/api.yaml, meant for development.
+	OpenAPIValidation bool `yaml:"openapi_validation"`
+}
+
+// Database holds the PostgreSQL connection settings.
+type Database struct {
+	Host     string `yaml:"host"`
+	Port     int    `yaml:"port"`
+	Name     string `yaml:"name"`
+	User     string `yaml:"user"`
+	Password string `yaml:"password"`
+	Schema   string `yaml:"schema"`
+	// The pool is a database/sql pool, it has no minimum size: only the max open and idle
+	// connection counts and the connection lifetimes below are applied.
+	MaxOpenConns    int           `yaml:"max_open_conns"`
+	MaxIdleConns    int           `yaml:"max_idle_conns"`
+	ConnMaxLifetime time.Duration `yaml:"conn_max_lifetime"`
+	ConnMaxIdleTime time.Duration `yaml:"conn_max_idle_time"`
+	// StatementCacheMode is the pgx query exec mode, use "exec" or "simple_protocol" behind PgBouncer.
+	StatementCacheMode string `yaml:"statement_cache_mode"`
+	// SSLMode is one of disable, allow, prefer, require, verify-ca or verify-full.
+	SSLMode     string `yaml:"ssl_mode"`
+	SSLRootCert string `yaml:"ssl_root_cert"`
+	// ConnectRetries is the number of additional ping attempts made on startup.
+	ConnectRetries int           `yaml:"connect_retries"`
+	ConnectBackoff time.Duration `yaml:"connect_backoff"`
+	// ReplicaDSN is an optional read replica connection string, reads go to the primary when empty.
+	ReplicaDSN string `yaml:"replica_dsn"`
+	// ReadYourWritesWindow pins a user to the primary for this long after a transfer or purchase.
+	ReadYourWritesWindow time.Duration `yaml:"read_your_writes_window"`
+}
+
+// Auth holds the authentication settings.
+type Auth struct {
+	JWTSecret string `yaml:"jwt_secret"`
+}
+
+// Wallet holds the coin wallet settings.
+type Wallet struct {
+	InitialCoins int `yaml:"initial_coins"`
+	// MaxBalance caps the balance a user can reach through transfers, refunds and admin credits,
+	// 0 means no cap.
+	MaxBalance int `yaml:"max_balance"`
+	// MinTransfer and MaxTransfer bound the amount of a single transfer, MaxTransfer 0 means no upper bound.
+	MinTransfer int `yaml:"min_transfer"`
+	MaxTransfer int `yaml:"max_transfer"`
+	// DailyTransferLimit is how many coins a user may send per UTC day, 0 means no limit.
+	DailyTransferLimit int `yaml:"daily_transfer_limit"`
+	// DailyPurchaseBudget is how many coins a user may spend in the shop per UTC day, 0 means no limit.
+	DailyPurchaseBudget int `yaml:"daily_purchase_budget"`
+}
+
+// Shop holds the merch shop settings.
+type Shop struct {
+	// RefundWindow is how long after the purchase a user may refund an order.
+	RefundWindow time.Duration `yaml:"refund_window"`
+	// LegacyBuyRoute keeps the deprecated GET /api/buy/:item route mounted. It changes state on
+	// a GET, so it is off unless an old client still needs it.
+	LegacyBuyRoute bool `yaml:"legacy_buy_route"`
+}
+
+// Outbox holds the domain events dispatcher settings.
+// Events always go to webhook subscriptions, the webhook URL and file sinks are optional.
+type Outbox struct {
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	Lease        time.Duration `yaml:"lease"`
+	BaseBackoff  time.Duration `yaml:"base_backoff"`
+	MaxBackoff   time.Duration `yaml:"max_backoff"`
+	// MaxAttempts dead-letters an event after that many failed deliveries.
+	MaxAttempts int `yaml:"max_attempts"`
+	// WebhookURL receives every event as an HTTP POST.
+	WebhookURL     string        `yaml:"webhook_url"`
+	WebhookTimeout time.Duration `yaml:"webhook_timeout"`
+	// File receives every event as a JSON line, "stdout" writes to the standard output.
+	File string `yaml:"file"`
+}
+
+// Webhooks holds the outbound webhook delivery settings.
+type Webhooks struct {
+	Timeout time.Duration `yaml:"timeout"`
+	// MaxConsecutiveFailures disables a subscription after that many failed deliveries in a row.
+	MaxConsecutiveFailures int `yaml:"max_consecutive_failures"`
+}
+
+// Scheduler holds the scheduled transfers settings.
+type Scheduler struct {
+	Enabled      bool          `yaml:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size"`
+	// LockKey is the Postgres advisory lock key electing the instance that runs transfers.
+	LockKey int `yaml:"lock_key"`
+	// MaxFailures deactivates a transfer after that many failed runs in a row.
+	MaxFailures int `yaml:"max_failures"`
+}
+
+// Default returns the configuration used when nothing else is set.
+func Default() *Config {
+	return &Config{
+		Env:      "local",
+		LogLevel: "info",
+		Server: Server{
+			Port:            defaultPort,
+			ReadTimeout:     10 * time.Second,
+			WriteTimeout:    30 * time.Second,
+			IdleTimeout:     time.Minute,
+			ShutdownTimeout: 5 * time.Second,
+			CORSOrigins:     []string{"http://localhost:5173"},
+		},
+		Database: Database{
+			Host:               "localhost",
+			Port:               5432,
+			Schema:             "public",
+			MaxOpenConns:       25,
+			MaxIdleConns:       25,
+			ConnMaxLifetime:    5 * time.Minute,
+			ConnMaxIdleTime:    time.Minute,
+			StatementCacheMode: "cache_statement",
+			SSLMode:            "disable",
+			ConnectRetries:     5,
+			ConnectBackoff:     500 * time.Millisecond,
+
+			ReadYourWritesWindow: 5 * time.Second,
+		},
+		Wallet: Wallet{
+			InitialCoins: defaultInitialCoins,
+			MinTransfer:  1,
+		},
+		Shop: Shop{
+			RefundWindow:   24 * time.Hour,
+			LegacyBuyRoute: false,
+		},
+		Outbox: Outbox{
+			PollInterval:   time.Second,
+			BatchSize:      100,
+			Lease:          time.Minute,
+			BaseBackoff:    time.Second,
+			MaxBackoff:     10 * time.Minute,
+			MaxAttempts:    20,
+			WebhookTimeout: 10 * time.Second,
+		},
+		Webhooks: Webhooks{
+			Timeout:                10 * time.Second,
+			MaxConsecutiveFailures: 10,
+		},
+		Scheduler: Scheduler{
+			Enabled:      true,
+			PollInterval: 10 * time.Second,
+			BatchSize:    100,
+			LockKey:      20250308,
+			MaxFailures:  3,
+		},
+	}
+}
+
+// Load builds the configuration from defaults, an optional YAML file,
+// the .env file and the environment, in increasing order of priority.
+// The YAML file path is taken from the CONFIG_FILE variable.
+func Load() (*Config, error) {
+	if err := godotenv.Load(); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("load .env: %w", err)
+	}
+
+	cfg := Default()
+	if path := os.Getenv("CONFIG_FILE"); path != "" {
+		if err := cfg.loadFile(path); err != nil {
+			return nil, err
+		}
+	}
+	if err := cfg.loadEnv(); err != nil {
+		return nil, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+func (c *Config) loadFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("read config file: %w", err)
+	}
+	if err := yaml.Unmarshal(data, c); err != nil {
+		return fmt.Errorf("parse config file %s: %w", path, err)
+	}
+	return nil
+}
+
+func (c *Config) loadEnv() error {
+	var e envReader
+	e.readString("APP_ENV", &c.Env)
+	e.readString("LOG_LEVEL", &c.LogLevel)
+	e.readBool("MIGRATE_ON_START", &c.MigrateOnStart)
+
+	e.readInt("PORT", &c.Server.Port)
+	e.readDuration("SERVER_READ_TIMEOUT", &c.Server.ReadTimeout)
+	e.readDuration("SERVER_WRITE_TIMEOUT", &c.Server.WriteTimeout)
+	e.readDuration("SERVER_IDLE_TIMEOUT", &c.Server.IdleTimeout)
+	e.readDuration("SERVER_SHUTDOWN_TIMEOUT", &c.Server.ShutdownTimeout)
+	e.readList("CORS_ALLOWED_ORIGINS", &c.Server.CORSOrigins)
+	e.readBool("SERVER_OPENAPI_VALIDATION", &c.Server.OpenAPIValidation)
+
+	e.readString("DB_HOST", &c.Database.Host)
+	e.readInt("DB_PORT", &c.Database.Port)
+	e.readString("DB_DATABASE", &c.Database.Name)
+	e.readString("DB_USERNAME", &c.Database.User)
+	e.readString("DB_PASSWORD", &c.Database.Password)
+	e.readString("DB_SCHEMA", &c.Database.Schema)
+	e.readInt("DB_MAX_OPEN_CONNS", &c.Database.MaxOpenConns)
+	e.readInt("DB_MAX_IDLE_CONNS", &c.Database.MaxIdleConns)
+	e.readDuration("DB_CONN_MAX_LIFETIME", &c.Database.ConnMaxLifetime)
+	e.readDuration("DB_CONN_MAX_IDLE_TIME", &c.Database.ConnMaxIdleTime)
+	e.readString("DB_STATEMENT_CACHE_MODE", &c.Database.StatementCacheMode)
+	e.readString("DB_SSL_MODE", &c.Database.SSLMode)
+	e.readString("DB_SSL_ROOT_CERT", &c.Database.SSLRootCert)
+	e.readInt("DB_CONNECT_RETRIES", &c.Database.ConnectRetries)
+	e.readDuration("DB_CONNECT_BACKOFF", &c.Database.ConnectBackoff)
+	e.readString("DB_REPLICA_DSN", &c.Database.ReplicaDSN)
+	e.readDuration("DB_READ_YOUR_WRITES_WINDOW", &c.Database.ReadYourWritesWindow)
+
+	e.readString("JWT_SECRET", &c.Auth.JWTSecret)
+
+	e.readInt("INITIAL_COINS", &c.Wallet.InitialCoins)
+	e.readInt("WALLET_MAX_BALANCE", &c.Wallet.MaxBalance)
+	e.readInt("WALLET_MIN_TRANSFER", &c.Wallet.MinTransfer)
+	e.readInt("WALLET_MAX_TRANSFER", &c.Wallet.MaxTransfer)
+	e.readInt("WALLET_DAILY_TRANSFER_LIMIT", &c.Wallet.DailyTransferLimit)
+	e.readInt("WALLET_DAILY_PURCHASE_BUDGET", &c.Wallet.DailyPurchaseBudget)
+	e.readDuration("SHOP_REFUND_WINDOW", &c.Shop.RefundWindow)
+	e.readBool("SHOP_LEGACY_BUY_ROUTE", &c.Shop.LegacyBuyRoute)
+
+	e.readDuration("OUTBOX_POLL_INTERVAL", &c.Outbox.PollInterval)
+	e.readInt("OUTBOX_BATCH_SIZE", &c.Outbox.BatchSize)
+	e.readDuration("OUTBOX_LEASE", &c.Outbox.Lease)
+	e.readDuration("OUTBOX_BASE_BACKOFF", &c.Outbox.BaseBackoff)
+	e.readDuration("OUTBOX_MAX_BACKOFF", &c.Outbox.MaxBackoff)
+	e.readInt("OUTBOX_MAX_ATTEMPTS", &c.Outbox.MaxAttempts)
+	e.readString("OUTBOX_WEBHOOK_URL", &c.Outbox.WebhookURL)
+	e.readDuration("OUTBOX_WEBHOOK_TIMEOUT", &c.Outbox.WebhookTimeout)
+	e.readString("OUTBOX_FILE", &c.Outbox.File)
+
+	e.readDuration("WEBHOOK_TIMEOUT", &c.Webhooks.Timeout)
+	e.readInt("WEBHOOK_MAX_FAILURES", &c.Webhooks.MaxConsecutiveFailures)
+
+	e.readBool("SCHEDULER_ENABLED", &c.Scheduler.Enabled)
+	e.readDuration("SCHEDULER_POLL_INTERVAL", &c.Scheduler.PollInterval)
+	e.readInt("SCHEDULER_BATCH_SIZE", &c.Scheduler.BatchSize)
+	e.readInt("SCHEDULER_LOCK_KEY", &c.Scheduler.LockKey)
+	e.readInt("SCHEDULER_MAX_FAILURES", &c.Scheduler.MaxFailures)
+	return e.err()
+}
+
+// Validate reports every invalid setting of the configuration.
+func (c *Config) Validate() error {
+	var errs []error
+	check := func(ok bool, format string, args ...any) {
+		if !ok {
+			errs = append(errs, fmt.Errorf(format, args...))
+		}
+	}
+
+	check(validPort(c.Server.Port), "server port %d is out of range", c.Server.Port)
+	check(c.Server.ReadTimeout > 0, "server read timeout must be positive")
+	check(c.Server.WriteTimeout > 0, "server write timeout must be positive")
+	check(c.Server.IdleTimeout > 0, "server idle timeout must be positive")
+	check(c.Server.ShutdownTimeout > 0, "server shutdown timeout must be positive")
+	check(len(c.Server.CORSOrigins) > 0, "at least one CORS origin is required")
+	for _, origin := range c.Server.CORSOrigins {
+		// The API allows credentials, and browsers reject a wildcard origin on credentialed requests.
+		check(origin != "*", "wildcard CORS origin is not allowed with credentials, list the origins explicitly")
+		check(origin == "*" || validOrigin(origin), "invalid CORS origin %q", origin)
+	}
+
+	check(c.Database.Host != "", "database host is required")
+	check(validPort(c.Database.Port), "database port %d is out of range", c.Database.Port)
+	check(c.Database.Name != "", "database name is required")
+	check(c.Database.User != "", "database user is required")
+	check(c.Database.MaxOpenConns >= 0, "database max open connections must not be negative")
+	check(c.Database.MaxIdleConns >= 0, "database max idle connections must not be negative")
+	check(c.Database.MaxOpenConns == 0 || c.Database.MaxIdleConns <= c.Database.MaxOpenConns,
+		"database max idle connections must not exceed max open connections")
+	check(c.Database.ConnMaxLifetime >= 0, "database connection max lifetime must not be negative")
+	check(c.Database.ConnMaxIdleTime >= 0, "database connection max idle time must not be negative")
+	check(slices.Contains(statementCacheModes, c.Database.StatementCacheMode),
+		"invalid database statement cache mode %q", c.Database.StatementCacheMode)
+	check(slices.Contains(sslModes, c.Database.SSLMode), "invalid database SSL mode %q", c.Database.SSLMode)
+	if c.Database.SSLRootCert != "" {
+		_, err := os.Stat(c.Database.SSLRootCert)
+		check(err == nil, "database SSL root certificate: %v", err)
+	}
+	check(c.Database.ConnectRetries >= 0, "database connect retries must not be negative")
+	check(c.Database.ConnectBackoff > 0, "database connect backoff must be positive")
+	check(c.Database.ReadYourWritesWindow >= 0, "database read-your-writes window must not be negative")
+
+	check(c.Auth.JWTSecret != "", "JWT secret is required")
+
+	check(c.Wallet.InitialCoins >= 0, "initial coins must not be negative")
+	check(c.Wallet.InitialCoins <= entities.MaxBalance, "initial coins must not exceed %d", entities.MaxBalance)
+	check(c.Wallet.MaxBalance >= 0, "wallet max balance must not be negative")
+	check(c.Wallet.MaxBalance <= entities.MaxBalance, "wallet max balance must not exceed %d", entities.MaxBalance)
+	check(c.Wallet.MaxBalance == 0 || c.Wallet.InitialCoins <= c.Wallet.MaxBalance, "initial coins must not exceed the wallet max balance")
+	check(c.Wallet.MinTransfer > 0, "wallet min transfer must be positive")
+	check(c.Wallet.MaxTransfer == 0 || c.Wallet.MaxTransfer >= c.Wallet.MinTransfer, "wallet max transfer must not be less than min transfer")
+	check(c.Wallet.DailyTransferLimit >= 0, "wallet daily transfer limit must not be negative")
+	check(c.Wallet.DailyPurchaseBudget >= 0, "wallet daily purchase budget must not be negative")
+	check(c.Shop.RefundWindow >= 0, "shop refund window must not be negative")
+
+	check(c.Outbox.PollInterval > 0, "outbox poll interval must be positive")
+	check(c.Outbox.BatchSize > 0, "outbox batch size must be positive")
+	check(c.Outbox.Lease > 0, "outbox lease must be positive")
+	check(c.Outbox.BaseBackoff > 0, "outbox base backoff must be positive")
+	check(c.Outbox.MaxBackoff >= c.Outbox.BaseBackoff, "outbox max backoff must not be less than base backoff")
+	check(c.Outbox.MaxAttempts > 0, "outbox max attempts must be positive")
+	check(c.Outbox.WebhookTimeout > 0, "outbox webhook timeout must be positive")
+	if c.Outbox.WebhookURL != "" {
+		check(validURL(c.Outbox.WebhookURL), "invalid outbox webhook URL %q", c.Outbox.WebhookURL)
+	}
+
+	check(c.Webhooks.Timeout > 0, "webhook timeout must be positive")
+	check(c.Webhooks.MaxConsecutiveFailures > 0, "webhook max consecutive failures must be positive")
+
+	check(c.Scheduler.PollInterval > 0, "scheduler poll interval must be positive")
+	check(c.Scheduler.BatchSize > 0, "scheduler batch size must be positive")
+	check(c.Scheduler.MaxFailures > 0, "scheduler max failures must be positive")
+
+	if len(errs) > 0 {
+		return fmt.Errorf("invalid configuration: %w", errors.Join(errs...))
+	}
+	return nil
+}
+
+func validPort(port int) bool {
+	return port > 0 && port <= 65535
+}
+
+func validURL(raw string) bool {
+	u, err := url.Parse(raw)
+	return err == nil && (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+func validOrigin(origin string) bool {
+	u, err := url.Parse(origin)
+	if err != nil {
+		return false
+	}
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != "" && (u.Path == "" || u.Path == "/")
+}
@@ -0,0 +1,117 @@
+package config
+
+import (
+	"avitotech/internal/entities"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func validConfig() *Config {
+	cfg := Default()
+	cfg.Database.Name = "avitotech"
+	cfg.Database.User = "avitotech"
+	cfg.Auth.JWTSecret = "secret"
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		modify func(*Config)
+		err    string
+	}{
+		{"valid", func(*Config) {}, ""},
+		{"port out of range", func(c *Config) { c.Server.Port = 70000 }, "server port 70000 is out of range"},
+		{"no CORS origins", func(c *Config) { c.Server.CORSOrigins = nil }, "at least one CORS origin is required"},
+		{"wildcard CORS origin", func(c *Config) { c.Server.CORSOrigins = []string{"*"} }, "wildcard CORS origin is not allowed"},
+		{"CORS origin with a path", func(c *Config) { c.Server.CORSOrigins = []string{"http://localhost:5173/app"} }, `invalid CORS origin "http://localhost:5173/app"`},
+		{"missing database name", func(c *Config) { c.Database.Name = "" }, "database name is required"},
+		{"idle above open connections", func(c *Config) { c.Database.MaxOpenConns, c.Database.MaxIdleConns = 5, 10 }, "must not exceed max open connections"},
+		{"unknown SSL mode", func(c *Config) { c.Database.SSLMode = "always" }, `invalid database SSL mode "always"`},
+		{"missing SSL root certificate", func(c *Config) { c.Database.SSLRootCert = "/nonexistent/root.crt" }, "database SSL root certificate"},
+		{"missing JWT secret", func(c *Config) { c.Auth.JWTSecret = "" }, "JWT secret is required"},
+		{"max balance above the storage limit", func(c *Config) { c.Wallet.MaxBalance = entities.MaxBalance + 1 }, "wallet max balance must not exceed"},
+		{"max balance at the storage limit", func(c *Config) { c.Wallet.MaxBalance = entities.MaxBalance }, ""},
+		{"initial coins above max balance", func(c *Config) { c.Wallet.MaxBalance = 100 }, "initial coins must not exceed the wallet max balance"},
+		{"initial coins above the storage limit", func(c *Config) { c.Wallet.InitialCoins = entities.MaxBalance + 1 }, "initial coins must not exceed 2147483647"},
+		{"initial coins at the storage limit", func(c *Config) { c.Wallet.InitialCoins = entities.MaxBalance }, ""},
+		{"max transfer below min transfer", func(c *Config) { c.Wallet.MinTransfer, c.Wallet.MaxTransfer = 10, 5 }, "wallet max transfer must not be less than min transfer"},
+		{"outbox max backoff below base", func(c *Config) { c.Outbox.MaxBackoff = time.Millisecond }, "outbox max backoff must not be less than base backoff"},
+		{"no outbox attempts", func(c *Config) { c.Outbox.MaxAttempts = 0 }, "outbox max attempts must be positive"},
+		{"invalid outbox webhook URL", func(c *Config) { c.Outbox.WebhookURL = "ftp://events" }, `invalid outbox webhook URL "ftp://events"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(cfg)
+			err := cfg.Validate()
+			if tt.err == "" {
+				if err != nil {
+					t.Fatalf("expected Validate() to return nil, got %v", err)
+				}
+				return
+			}
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("expected Validate() error containing %q, got %v", tt.err, err)
+			}
+		})
+	}
+}
+
+func TestValidateReportsEveryError(t *testing.T) {
+	cfg := validConfig()
+	cfg.Server.Port = 0
+	cfg.Auth.JWTSecret = ""
+	err := cfg.Validate()
+	for _, want := range []string{"server port 0 is out of range", "JWT secret is required"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Fatalf("expected Validate() error containing %q, got %v", want, err)
+		}
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(*Config) any
+		want  any
+		err   string
+	}{
+		{"string", map[string]string{"DB_HOST": "db.internal"}, func(c *Config) any { return c.Database.Host }, "db.internal", ""},
+		{"int", map[string]string{"PORT": "9090"}, func(c *Config) any { return c.Server.Port }, 9090, ""},
+		{"bool", map[string]string{"MIGRATE_ON_START": "true"}, func(c *Config) any { return c.MigrateOnStart }, true, ""},
+		{"legacy buy route is opt-in", nil, func(c *Config) any { return c.Shop.LegacyBuyRoute }, false, ""},
+		{"legacy buy route enabled", map[string]string{"SHOP_LEGACY_BUY_ROUTE": "true"}, func(c *Config) any { return c.Shop.LegacyBuyRoute }, true, ""},
+		{"duration", map[string]string{"SHOP_REFUND_WINDOW": "48h"}, func(c *Config) any { return c.Shop.RefundWindow }, 48 * time.Hour, ""},
+		{"list", map[string]string{"CORS_ALLOWED_ORIGINS": " https://a.example , ,https://b.example"},
+			func(c *Config) any { return c.Server.CORSOrigins }, []string{"https://a.example", "https://b.example"}, ""},
+		{"blank keeps the default", map[string]string{"PORT": "  "}, func(c *Config) any { return c.Server.Port }, defaultPort, ""},
+		{"invalid int", map[string]string{"PORT": "eighty"}, nil, nil, `PORT="eighty"`},
+		{"invalid bool", map[string]string{"SCHEDULER_ENABLED": "maybe"}, nil, nil, `SCHEDULER_ENABLED="maybe"`},
+		{"invalid duration", map[string]string{"OUTBOX_LEASE": "30"}, nil, nil, `OUTBOX_LEASE="30"`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg := Default()
+			err := cfg.loadEnv()
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("expected loadEnv() error containing %q, got %v", tt.err, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("expected loadEnv() to return nil, got %v", err)
+			}
+			if got := tt.check(cfg); !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
@@ -1,14 +1,86 @@
 package customErrors
 
-import "errors"
+import (
+	"errors"
+	"net/http"
+)
+
+// Error is a domain error. Code is a stable identifier clients can branch on, Status is the HTTP status
+// the error maps to and Message is safe to show to users.
+type Error struct {
+	Code    string
+	Status  int
+	Message string
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+func newError(code string, status int, message string) *Error {
+	return &Error{Code: code, Status: status, Message: message}
+}
 
 var (
-	ErrNotFound           = errors.New("not found")
-	ErrInvalidData        = errors.New("invalid data")
-	ErrNotEnoughCoins     = errors.New("not enough coins")
-	ErrInvalidUsername    = errors.New("invalid username")
-	ErrUnauthorized       = errors.New("unauthorized")
-	ErrInvalidRequest     = errors.New("invalid request body")
-	ErrInvalidCredentials = errors.New("invalid credentials")
-	ErrISE                = errors.New("internal server error")
+	ErrNotFound            = newError("not_found", http.StatusNotFound, "not found")
+	ErrInvalidData         = newError("invalid_data", http.StatusBadRequest, "invalid data")
+	ErrNotEnoughCoins      = newError("not_enough_coins", http.StatusBadRequest, "not enough coins")
+	ErrInvalidUsername     = newError("invalid_username", http.StatusBadRequest, "invalid username")
+	ErrUnauthorized        = newError("unauthorized", http.StatusUnauthorized, "unauthorized")
+	ErrForbidden           = newError("forbidden", http.StatusForbidden, "forbidden")
+	ErrInvalidRequest      = newError("invalid_request", http.StatusBadRequest, "invalid request body")
+	ErrInvalidCredentials  = newError("invalid_credentials", http.StatusUnauthorized, "invalid credentials")
+	ErrISE                 = newError("internal_error", http.StatusInternalServerError, "internal server error")
+	ErrInvalidTransition   = newError("invalid_transition", http.StatusConflict, "invalid order status transition")
+	ErrRefundWindowExpired = newError("refund_window_expired", http.StatusConflict, "refund window expired")
+	ErrUnknownItem         = newError("unknown_item", http.StatusBadRequest, "unknown item")
+	ErrOutOfStock          = newError("out_of_stock", http.StatusConflict, "out of stock")
+	ErrPurchaseLimit       = newError("purchase_limit", http.StatusConflict, "purchase limit exceeded")
+	ErrUnsupportedMedia    = newError("unsupported_media_type", http.StatusUnsupportedMediaType, "content type must be application/json")
+	ErrRequestResolved     = newError("request_resolved", http.StatusConflict, "coin request already resolved")
+	ErrTransferTooSmall    = newError("transfer_too_small", http.StatusBadRequest, "transfer amount is below the minimum")
+	ErrTransferTooLarge    = newError("transfer_too_large", http.StatusBadRequest, "transfer amount is above the maximum")
+	ErrMaxBalance          = newError("max_balance", http.StatusConflict, "recipient balance limit exceeded")
+	ErrDailyTransferLimit  = newError("daily_transfer_limit", http.StatusForbidden, "daily transfer limit exceeded")
+	ErrDailyPurchaseBudget = newError("daily_purchase_budget", http.StatusForbidden, "daily purchase budget exceeded")
+	ErrInvalidAmount       = newError("invalid_amount", http.StatusBadRequest, "amount must be a positive number of coins up to 1000000000")
+	ErrBalanceOverflow     = newError("balance_overflow", http.StatusConflict, "balance would exceed the maximum wallet balance")
+	ErrUserDeactivated     = newError("user_deactivated", http.StatusForbidden, "user is deactivated")
 )
+
+// As finds the domain error in err's chain.
+func As(err error) (*Error, bool) {
+	var domainErr *Error
+	if errors.As(err, &domainErr) {
+		return domainErr, true
+	}
+	return nil, false
+}
+
+// detailedError carries machine-readable details of an error.
+type detailedError struct {
+	err     error
+	details map[string]any
+}
+
+func (e *detailedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *detailedError) Unwrap() error {
+	return e.err
+}
+
+// WithDetails attaches machine-readable details to err, e.g. which rows of a rejected batch failed.
+func WithDetails(err error, details map[string]any) error {
+	return &detailedError{err: err, details: details}
+}
+
+// Details returns the details attached to err with WithDetails, or nil.
+func Details(err error) map[string]any {
+	var detailed *detailedError
+	if errors.As(err, &detailed) {
+		return detailed.details
+	}
+	return nil
+}
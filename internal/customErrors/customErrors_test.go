@@ -0,0 +1,32 @@
+package customErrors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestAs(t *testing.T) {
+	err := WithDetails(fmt.Errorf("%w: window must be one of day, week", ErrInvalidData), map[string]any{"field": "window"})
+	domainErr, ok := As(err)
+	if !ok || domainErr != ErrInvalidData || domainErr.Status != http.StatusBadRequest {
+		t.Fatalf("expected As() to find ErrInvalidData, got %v, %v", domainErr, ok)
+	}
+	if !errors.Is(err, ErrInvalidData) {
+		t.Fatalf("expected a detailed error to still match its sentinel")
+	}
+	if err.Error() != "invalid data: window must be one of day, week" {
+		t.Fatalf("expected the message of the wrapped error, got %q", err.Error())
+	}
+	if details := Details(err); details["field"] != "window" {
+		t.Fatalf("expected Details() to return the attached details, got %v", details)
+	}
+
+	if _, ok := As(errors.New("connection refused")); ok {
+		t.Fatalf("expected As() to reject errors outside the domain")
+	}
+	if details := Details(ErrNotFound); details != nil {
+		t.Fatalf("expected no details on a bare sentinel, got %v", details)
+	}
+}
@@ -0,0 +1,129 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"database/sql"
+	"slices"
+)
+
+// AdjustmentStore lets admins issue and take away coins outside of transfers.
+type AdjustmentStore interface {
+	// MintCoins credits the user with new coins and returns the new balance.
+	MintCoins(actorId, userId, amount int, reason string) (int, error)
+	// DebitCoins takes coins away from the user and returns the new balance.
+	DebitCoins(actorId, userId, amount int, reason string) (int, error)
+	// AwardCoins credits every recipient with new coins in one transaction, all or nothing.
+	AwardCoins(actorId int, awards []entities.Transfer) error
+}
+
+// MintCoins credits the user with new coins and returns the new balance.
+func (s *service) MintCoins(actorId, userId, amount int, reason string) (int, error) {
+	var balance int
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		balance, err = s.adjustCoins(tx, userId, amount, &entities.Transaction{
+			ToUserID: userId,
+			Amount:   amount,
+			Type:     entities.TransactionMint,
+			Message:  reason,
+			ActorID:  actorId,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.pins.pin(s.clock.Now(), userId)
+	return balance, nil
+}
+
+// DebitCoins takes coins away from the user and returns the new balance.
+// It fails with ErrNotEnoughCoins rather than leaving the balance negative.
+func (s *service) DebitCoins(actorId, userId, amount int, reason string) (int, error) {
+	var balance int
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		balance, err = s.adjustCoins(tx, userId, -amount, &entities.Transaction{
+			FromUserID: userId,
+			Amount:     amount,
+			Type:       entities.TransactionDebit,
+			Message:    reason,
+			ActorID:    actorId,
+		})
+		return err
+	})
+	if err != nil {
+		return 0, err
+	}
+	s.pins.pin(s.clock.Now(), userId)
+	return balance, nil
+}
+
+// AwardCoins credits every recipient with new coins in one transaction, all or nothing.
+func (s *service) AwardCoins(actorId int, awards []entities.Transfer) error {
+	userIds := make([]int, 0, len(awards))
+	for _, award := range awards {
+		userIds = append(userIds, award.ToUserID)
+	}
+	// Lock the wallets in a fixed order to avoid deadlocks with transfers.
+	slices.Sort(userIds)
+	userIds = slices.Compact(userIds)
+
+	err := s.inTx(func(tx *sql.Tx) error {
+		for _, userId := range userIds {
+			if _, err := s.lockCoins(tx, userId); err != nil {
+				return err
+			}
+		}
+		for _, award := range awards {
+			_, err := s.adjustCoins(tx, award.ToUserID, award.Amount, &entities.Transaction{
+				ToUserID: award.ToUserID,
+				Amount:   award.Amount,
+				Type:     entities.TransactionAward,
+				Message:  award.Message,
+				ActorID:  actorId,
+			})
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+	s.pins.pin(s.clock.Now(), userIds...)
+	return nil
+}
+
+// adjustCoins changes the balance of the user by delta and records the ledger entry.
+func (s *service) adjustCoins(tx *sql.Tx, userId, delta int, transaction *entities.Transaction) (int, error) {
+	coins, err := s.lockCoins(tx, userId)
+	if err != nil {
+		return 0, err
+	}
+	if coins+delta < 0 {
+		return 0, customErrors.ErrNotEnoughCoins
+	}
+	// Deactivated users receive no coins, taking coins away from them is still allowed.
+	// Credits are capped like transfers are.
+	if delta > 0 {
+		if err := checkActive(tx, userId, userId); err != nil {
+			return 0, err
+		}
+		if !entities.CanCredit(coins, delta) {
+			return 0, customErrors.ErrBalanceOverflow
+		}
+		if err := s.checkMaxBalance(tx, userId, coins, delta); err != nil {
+			return 0, err
+		}
+	}
+	if err := s.updateCoins(tx, userId, coins+delta); err != nil {
+		return 0, err
+	}
+	if err := s.saveTransaction(tx, transaction); err != nil {
+		return 0, err
+	}
+	return coins + delta, nil
+}
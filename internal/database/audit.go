@@ -0,0 +1,138 @@
+package database
+
+import (
+	"avitotech/internal/entities"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// auditLockKey serializes appends to the audit log so that the hash chain stays linear.
+const auditLockKey = 20250310
+
+// AuditStore keeps the append-only, hash-chained audit log.
+type AuditStore interface {
+	// AppendAuditEntry chains the entry to the last one and appends it to the log.
+	AppendAuditEntry(entry *entities.AuditEntry) error
+	// GetAuditEntries retrieves the latest entries matching the filter.
+	GetAuditEntries(filter entities.AuditFilter, limit int) ([]entities.AuditEntry, error)
+	// VerifyAuditLog recomputes the hash chain and returns the number of entries checked
+	// and the ID of the first entry that does not match, 0 when the chain is intact.
+	VerifyAuditLog() (int64, int64, error)
+}
+
+const auditEntryColumns = "id, action, COALESCE(actor_id, 0), target, details, ip, user_agent, request_id, created_at, prev_hash, hash"
+
+func scanAuditEntry(row rowScanner) (*entities.AuditEntry, error) {
+	var entry entities.AuditEntry
+	var details string
+	err := row.Scan(&entry.ID, &entry.Action, &entry.ActorID, &entry.Target, &details, &entry.IP, &entry.UserAgent, &entry.RequestID, &entry.CreatedAt, &entry.PrevHash, &entry.Hash)
+	if err != nil {
+		return nil, err
+	}
+	entry.Details = []byte(details)
+	return &entry, nil
+}
+
+// AppendAuditEntry chains the entry to the last one and appends it to the log.
+// The entry's ID, creation time and hashes are set by the store.
+func (s *service) AppendAuditEntry(entry *entities.AuditEntry) error {
+	if len(entry.Details) == 0 {
+		entry.Details = []byte("{}")
+	}
+	// Postgres keeps microseconds, the hash must be computed over what is stored.
+	entry.CreatedAt = s.clock.Now().UTC().Truncate(time.Microsecond)
+	return s.inTx(func(tx *sql.Tx) error {
+		if _, err := tx.Exec("SELECT pg_advisory_xact_lock($1)", auditLockKey); err != nil {
+			return err
+		}
+		err := tx.QueryRow("SELECT hash FROM audit_log ORDER BY id DESC LIMIT 1").Scan(&entry.PrevHash)
+		if errors.Is(err, sql.ErrNoRows) {
+			entry.PrevHash = entities.AuditGenesisHash
+		} else if err != nil {
+			return err
+		}
+		entry.Hash = entry.ComputeHash()
+		return tx.QueryRow(`INSERT INTO audit_log (action, actor_id, target, details, ip, user_agent, request_id, created_at, prev_hash, hash)
+			VALUES ($1, NULLIF($2, 0), $3, $4, $5, $6, $7, $8, $9, $10) RETURNING id`,
+			entry.Action, entry.ActorID, entry.Target, string(entry.Details), entry.IP, entry.UserAgent, entry.RequestID, entry.CreatedAt, entry.PrevHash, entry.Hash).Scan(&entry.ID)
+	})
+}
+
+// GetAuditEntries retrieves the latest entries matching the filter.
+func (s *service) GetAuditEntries(filter entities.AuditFilter, limit int) ([]entities.AuditEntry, error) {
+	var conditions []string
+	var args []any
+	where := func(condition string, arg any) {
+		args = append(args, arg)
+		conditions = append(conditions, fmt.Sprintf(condition, len(args)))
+	}
+	if filter.ActorID != 0 {
+		where("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		where("action = $%d", filter.Action)
+	}
+	if filter.Target != "" {
+		where("target = $%d", filter.Target)
+	}
+	if !filter.From.IsZero() {
+		where("created_at >= $%d", filter.From)
+	}
+	if !filter.To.IsZero() {
+		where("created_at < $%d", filter.To)
+	}
+	if filter.BeforeID != 0 {
+		where("id < $%d", filter.BeforeID)
+	}
+	query := "SELECT " + auditEntryColumns + " FROM audit_log"
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, limit)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var entries []entities.AuditEntry
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return nil, err
+		}
+		entries = append(entries, *entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// VerifyAuditLog recomputes the hash chain and returns the number of entries checked
+// and the ID of the first entry that does not match, 0 when the chain is intact.
+func (s *service) VerifyAuditLog() (int64, int64, error) {
+	rows, err := s.db.Query("SELECT " + auditEntryColumns + " FROM audit_log ORDER BY id")
+	if err != nil {
+		return 0, 0, err
+	}
+	defer rows.Close()
+	var checked int64
+	prevHash := entities.AuditGenesisHash
+	for rows.Next() {
+		entry, err := scanAuditEntry(rows)
+		if err != nil {
+			return checked, 0, err
+		}
+		checked++
+		if entry.PrevHash != prevHash || entry.ComputeHash() != entry.Hash {
+			return checked, entry.ID, nil
+		}
+		prevHash = entry.Hash
+	}
+	return checked, 0, rows.Err()
+}
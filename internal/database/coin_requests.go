@@ -0,0 +1,124 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"database/sql"
+	"errors"
+)
+
+// CoinRequestStore manages requests of users to be paid by other users.
+type CoinRequestStore interface {
+	// CreateCoinRequest inserts a new pending coin request.
+	CreateCoinRequest(request *entities.CoinRequest) error
+	// GetCoinRequest retrieves the coin request by the given ID.
+	GetCoinRequest(id int) (*entities.CoinRequest, error)
+	// GetCoinRequestsByUserID retrieves the latest requests made by or to the user, optionally filtered by status.
+	GetCoinRequestsByUserID(userId int, status entities.CoinRequestStatus, limit int) ([]entities.CoinRequest, error)
+	// ResolveCoinRequest approves or declines a pending request addressed to the payer.
+	// Approving transfers the coins in the same transaction.
+	ResolveCoinRequest(id, payerId int, approve bool) (*entities.CoinRequest, error)
+}
+
+const coinRequestColumns = "id, requester_id, payer_id, amount, message, status, created_at, updated_at"
+
+func scanCoinRequest(row rowScanner) (*entities.CoinRequest, error) {
+	var request entities.CoinRequest
+	err := row.Scan(&request.ID, &request.RequesterID, &request.PayerID, &request.Amount, &request.Message, &request.Status, &request.CreatedAt, &request.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &request, nil
+}
+
+// CreateCoinRequest inserts a new pending coin request.
+func (s *service) CreateCoinRequest(request *entities.CoinRequest) error {
+	return s.inTx(func(tx *sql.Tx) error {
+		now := s.clock.Now()
+		request.Status = entities.CoinRequestPending
+		request.CreatedAt = now
+		request.UpdatedAt = now
+		err := tx.QueryRow("INSERT INTO coin_requests (requester_id, payer_id, amount, message, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $6) RETURNING id",
+			request.RequesterID, request.PayerID, request.Amount, request.Message, request.Status, now).Scan(&request.ID)
+		if err != nil {
+			return err
+		}
+		return s.saveEvent(tx, events.CoinsRequested, events.CoinsRequestedPayload{
+			RequestID:   request.ID,
+			RequesterID: request.RequesterID,
+			PayerID:     request.PayerID,
+			Amount:      request.Amount,
+			Message:     request.Message,
+		})
+	})
+}
+
+// GetCoinRequest retrieves the coin request by the given ID.
+func (s *service) GetCoinRequest(id int) (*entities.CoinRequest, error) {
+	request, err := scanCoinRequest(s.db.QueryRow("SELECT "+coinRequestColumns+" FROM coin_requests WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return request, err
+}
+
+// GetCoinRequestsByUserID retrieves the latest requests made by or to the user, optionally filtered by status.
+func (s *service) GetCoinRequestsByUserID(userId int, status entities.CoinRequestStatus, limit int) ([]entities.CoinRequest, error) {
+	rows, err := s.reader(userId).Query("SELECT "+coinRequestColumns+" FROM coin_requests WHERE (requester_id = $1 OR payer_id = $1) AND ($2 = '' OR status = $2) ORDER BY id DESC LIMIT $3",
+		userId, status, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var requests []entities.CoinRequest
+	for rows.Next() {
+		request, err := scanCoinRequest(rows)
+		if err != nil {
+			return nil, err
+		}
+		requests = append(requests, *request)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return requests, nil
+}
+
+// ResolveCoinRequest approves or declines a pending request addressed to the payer.
+// Approving transfers the coins in the same transaction.
+func (s *service) ResolveCoinRequest(id, payerId int, approve bool) (*entities.CoinRequest, error) {
+	var request *entities.CoinRequest
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		request, err = scanCoinRequest(tx.QueryRow("SELECT "+coinRequestColumns+" FROM coin_requests WHERE id = $1 FOR UPDATE", id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return customErrors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		// Requests addressed to someone else look missing.
+		if request.PayerID != payerId {
+			return customErrors.ErrNotFound
+		}
+		if request.Status != entities.CoinRequestPending {
+			return customErrors.ErrRequestResolved
+		}
+		request.Status = entities.CoinRequestDeclined
+		if approve {
+			request.Status = entities.CoinRequestApproved
+			if err := s.transfer(tx, request.PayerID, request.RequesterID, request.Amount, request.Message); err != nil {
+				return err
+			}
+		}
+		request.UpdatedAt = s.clock.Now()
+		_, err = tx.Exec("UPDATE coin_requests SET status = $1, updated_at = $2 WHERE id = $3", request.Status, request.UpdatedAt, request.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.pins.pin(s.clock.Now(), request.PayerID, request.RequesterID)
+	return request, nil
+}
@@ -0,0 +1,88 @@
+package database
+
+import (
+	"avitotech/internal/config"
+	"context"
+	"database/sql"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/url"
+	"strconv"
+	"time"
+
+	_ "github.com/jackc/pgx/v5/stdlib"
+)
+
+// Open opens a connection pool to the configured database and waits until it answers a ping,
+// retrying with exponential backoff.
+func Open(ctx context.Context, cfg config.Database) (*sql.DB, error) {
+	return openDSN(ctx, connString(cfg), cfg)
+}
+
+// OpenReplica opens a connection pool to the read replica, it returns nil if no replica is configured.
+func OpenReplica(ctx context.Context, cfg config.Database) (*sql.DB, error) {
+	if cfg.ReplicaDSN == "" {
+		return nil, nil
+	}
+	db, err := openDSN(ctx, cfg.ReplicaDSN, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("replica: %w", err)
+	}
+	return db, nil
+}
+
+func openDSN(ctx context.Context, dsn string, cfg config.Database) (*sql.DB, error) {
+	db, err := sql.Open("pgx", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	if err := pingWithRetry(ctx, db, cfg.ConnectRetries, cfg.ConnectBackoff); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func connString(cfg config.Database) string {
+	params := url.Values{
+		"sslmode":                 {cfg.SSLMode},
+		"search_path":             {cfg.Schema},
+		"default_query_exec_mode": {cfg.StatementCacheMode},
+	}
+	if cfg.SSLRootCert != "" {
+		params.Set("sslrootcert", cfg.SSLRootCert)
+	}
+	connURL := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(cfg.User, cfg.Password),
+		Host:     net.JoinHostPort(cfg.Host, strconv.Itoa(cfg.Port)),
+		Path:     cfg.Name,
+		RawQuery: params.Encode(),
+	}
+	return connURL.String()
+}
+
+func pingWithRetry(ctx context.Context, db *sql.DB, retries int, backoff time.Duration) error {
+	var err error
+	for attempt := 0; ; attempt++ {
+		if err = db.PingContext(ctx); err == nil {
+			return nil
+		}
+		if attempt >= retries {
+			return fmt.Errorf("ping database after %d attempts: %w", attempt+1, err)
+		}
+		slog.Warn("Database is not available, retrying", "attempt", attempt+1, "backoff", backoff, "error", err)
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(backoff):
+		}
+		backoff *= 2
+	}
+}
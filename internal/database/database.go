@@ -1,80 +1,105 @@
 package database
 
 import (
+	"avitotech/internal/config"
 	"avitotech/internal/customErrors"
 	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"avitotech/internal/logging"
+	"avitotech/pkg/clock"
 	"avitotech/pkg/imcache"
+	"context"
 	"database/sql"
 	"errors"
-	"fmt"
-	"log"
 	"log/slog"
-	"os"
+	"slices"
 	"strconv"
-	"time"
-
-	_ "github.com/jackc/pgx/v5/stdlib"
-	_ "github.com/joho/godotenv/autoload"
 )
 
 // Service represents a service that interacts with a database.
 type Service interface {
+	OutboxStore
+	WebhookStore
+	OrderStore
+	StockStore
+	CoinRequestStore
+	ScheduledTransferStore
+	AdjustmentStore
+	AuditStore
+	WalletStore
+	StatsStore
+	UserStore
+
+	// Ping verifies the database connection is alive.
+	Ping(ctx context.Context) error
+	// Stats returns the connection pool statistics.
+	Stats() sql.DBStats
 	// Close terminates the database connection.
 	// It returns an error if the connection cannot be closed.
 	Close() error
 	// GetUserByName retrieves the user by the given username.
 	GetUserByName(username string) (*entities.User, error)
-	// GetUserNameById retrieves the username by the given user ID.
-	GetUserNameById(userId int) string
+	// GetUserByID retrieves the user by the given user ID.
+	GetUserByID(userId int) (*entities.User, error)
+	// SetUserAdmin grants or revokes the admin role of the user.
+	SetUserAdmin(username string, isAdmin bool) error
+	// GetUserNameById retrieves the username by the given user ID, "<unknown>" if the lookup fails.
+	GetUserNameById(ctx context.Context, userId int) string
 	// AddUser inserts a new user into the database.
-	AddUser(user *entities.User) error
+	AddUser(ctx context.Context, user *entities.User) error
 	// GetCoinsByUserID retrieves the number of coins by the given user ID
 	GetCoinsByUserID(userId int) (int, error)
 	// GetInventoryByUserID retrieves the inventory items by the given user ID.
 	GetInventoryByUserID(userId int) ([]entities.InventoryItem, error)
 	// GetTransactionsByUserID retrieves the transactions by the given user ID.
 	GetTransactionsByUserID(userId int) ([]entities.Transaction, error)
-	// SendCoin sends coins from one user to another.
-	SendCoin(fromUserID, toUserID, amount int) error
-	// BuyItem buys an item for the given user.
-	BuyItem(userId int, itemType string) error
+	// GetShopItems retrieves the shop catalog.
+	GetShopItems() ([]entities.ShopItem, error)
+	// SendCoin sends coins from one user to another with an optional message.
+	SendCoin(fromUserID, toUserID, amount int, message string) error
+	// SendCoinBulk sends coins from one user to each recipient in one transaction, all or nothing.
+	SendCoinBulk(fromUserID int, transfers []entities.Transfer) error
+	// BuyItem buys units of an item for the given user and places an order for them.
+	BuyItem(userId int, itemType string, quantity int) (*entities.Order, error)
+	// Checkout buys every line of the cart in one transaction, placing an order per item.
+	Checkout(userId int, lines []entities.CartLine) ([]entities.Order, error)
 }
 
-type service struct {
-	db    *sql.DB
-	cache imcache.Cache
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	Exec(query string, args ...any) (sql.Result, error)
+	Query(query string, args ...any) (*sql.Rows, error)
+	QueryRow(query string, args ...any) *sql.Row
 }
 
-var (
-	database   = os.Getenv("DB_DATABASE")
-	password   = os.Getenv("DB_PASSWORD")
-	username   = os.Getenv("DB_USERNAME")
-	port       = os.Getenv("DB_PORT")
-	host       = os.Getenv("DB_HOST")
-	schema     = os.Getenv("DB_SCHEMA")
-	dbInstance *service
-)
+type service struct {
+	db      *sql.DB
+	replica *sql.DB
+	pins    *primaryPins
+	cache   imcache.Cache
+	clock   clock.Clock
+	logger  *slog.Logger
+	name    string
+	wallet  config.Wallet
+}
 
-const (
-	INITIAL_COINS = 1000
-)
+// Option configures optional Service behaviour.
+type Option func(*service)
 
-func New() Service {
-	// Reuse Connection
-	if dbInstance != nil {
-		return dbInstance
+// New creates a Service on top of the given connection pool.
+func New(db *sql.DB, cache imcache.Cache, cfg *config.Config, clk clock.Clock, logger *slog.Logger, opts ...Option) Service {
+	s := &service{
+		db:     db,
+		cache:  cache,
+		clock:  clk,
+		logger: logger,
+		name:   cfg.Database.Name,
+		wallet: cfg.Wallet,
 	}
-	connStr := fmt.Sprintf("postgres://%s:%s@%s:%s/%s?sslmode=disable&search_path=%s", username, password, host, port, database, schema)
-	db, err := sql.Open("pgx", connStr)
-	if err != nil {
-		log.Fatal(err)
-	}
-	cache := imcache.NewInMemoryCache(5 * time.Minute)
-	dbInstance = &service{
-		db:    db,
-		cache: cache,
+	for _, opt := range opts {
+		opt(s)
 	}
-	return dbInstance
+	return s
 }
 
 // Close closes the database connection.
@@ -82,57 +107,138 @@ func New() Service {
 // If the connection is successfully closed, it returns nil.
 // If an error occurs while closing the connection, it returns the error.
 func (s *service) Close() error {
-	slog.Info("Disconnected from", "database", database)
+	s.logger.Info("Disconnected from", "database", s.name)
 	return s.db.Close()
 }
 
+// Ping verifies the database connection is alive.
+func (s *service) Ping(ctx context.Context) error {
+	return s.db.PingContext(ctx)
+}
+
+// Stats returns the connection pool statistics.
+func (s *service) Stats() sql.DBStats {
+	return s.db.Stats()
+}
+
+// userCacheKey is the cache key of the user with the given username. The cache is shared with
+// other services, so every key the store uses is namespaced.
+func userCacheKey(username string) string {
+	return "user:" + username
+}
+
+// activeCacheKey is the cache key of whether the user with the given ID is active.
+func activeCacheKey(userId int) string {
+	return "active:" + strconv.Itoa(userId)
+}
+
+// inventoryCacheKey is the cache key of the inventory of the user with the given ID.
+func inventoryCacheKey(userId int) string {
+	return "inventory:" + strconv.Itoa(userId)
+}
+
+// inTx runs fn in a transaction on the primary, committing if it returns nil.
+func (s *service) inTx(fn func(tx *sql.Tx) error) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := fn(tx); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
 // GetUserByName retrieves the user by the given username.
 func (s *service) GetUserByName(username string) (*entities.User, error) {
-	if user, ok := s.cache.Get(username); ok {
-		return user.(*entities.User), nil
+	if cached, ok := s.cache.Get(userCacheKey(username)); ok {
+		if user, ok := cached.(*entities.User); ok {
+			return user, nil
+		}
 	}
-	user := &entities.User{}
-	row := s.db.QueryRow("SELECT id, username, password, created_at, updated_at FROM users WHERE username = $1", username)
-	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	user, err := scanUser(s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE username = $1", username))
 	if errors.Is(err, sql.ErrNoRows) {
 		return nil, nil
 	}
 	if err != nil {
 		return nil, err
 	}
-	s.cache.Set(username, user)
+	s.cache.Set(userCacheKey(username), user)
 	return user, nil
 }
 
-// GetUserNameById retrieves the username by the given user ID.
-func (s *service) GetUserNameById(userId int) string {
+// GetUserByID retrieves the user by the given user ID.
+func (s *service) GetUserByID(userId int) (*entities.User, error) {
+	user, err := scanUser(s.db.QueryRow("SELECT "+userColumns+" FROM users WHERE id = $1", userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
+// SetUserAdmin grants or revokes the admin role of the user.
+func (s *service) SetUserAdmin(username string, isAdmin bool) error {
+	res, err := s.db.Exec("UPDATE users SET is_admin = $1, updated_at = $2 WHERE username = $3", isAdmin, s.clock.Now(), username)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return customErrors.ErrNotFound
+	}
+	s.cache.Delete(userCacheKey(username))
+	return nil
+}
+
+// GetUserNameById retrieves the username by the given user ID, "<unknown>" if the lookup fails.
+// Failures other than a missing user are logged with the logger of ctx.
+func (s *service) GetUserNameById(ctx context.Context, userId int) string {
 	var username string
 	row := s.db.QueryRow("SELECT username FROM users WHERE id = $1", userId)
 	err := row.Scan(&username)
 	if err != nil {
+		if !errors.Is(err, sql.ErrNoRows) {
+			logging.FromContext(ctx, s.logger).Warn("Username lookup failed", "user_id", userId, "error", err)
+		}
 		return "<unknown>"
 	}
 	return username
 }
 
 // AddUser inserts a new user into the database.
-func (s *service) AddUser(user *entities.User) error {
-	var userId int
-	err := s.db.QueryRow("INSERT INTO users (username, password, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id", user.Username, user.Password, user.CreatedAt, user.UpdatedAt).Scan(&userId)
+func (s *service) AddUser(ctx context.Context, user *entities.User) error {
+	err := s.inTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow("INSERT INTO users (username, password, created_at, updated_at) VALUES ($1, $2, $3, $4) RETURNING id", user.Username, user.Password, user.CreatedAt, user.UpdatedAt).Scan(&user.ID)
+		if err != nil {
+			return err
+		}
+		if err := s.initUserWallet(tx, user.ID); err != nil {
+			return err
+		}
+		return s.saveEvent(tx, events.UserRegistered, events.UserRegisteredPayload{
+			UserID:   user.ID,
+			Username: user.Username,
+		})
+	})
 	if err != nil {
 		return err
 	}
-	if err := s.InitUserWallet(userId); err != nil {
-		return err
-	}
-	slog.Info("User created and added coins to his wallet", "username", user.Username, "coins", INITIAL_COINS)
+	logging.FromContext(ctx, s.logger).Info("User created and added coins to his wallet", "username", user.Username, "coins", s.wallet.InitialCoins)
 	return nil
 }
 
 // GetCoinsByUserID retrieves the number of coins by the given user ID.
 func (s *service) GetCoinsByUserID(userId int) (int, error) {
+	return s.getCoins(s.reader(userId), userId)
+}
+
+func (s *service) getCoins(q querier, userId int) (int, error) {
 	var coins int
-	row := s.db.QueryRow("SELECT amount FROM coins WHERE user_id = $1", userId)
+	row := q.QueryRow("SELECT amount FROM coins WHERE user_id = $1", userId)
 	err := row.Scan(&coins)
 	if errors.Is(err, sql.ErrNoRows) {
 		return 0, nil
@@ -143,19 +249,32 @@ func (s *service) GetCoinsByUserID(userId int) (int, error) {
 	return coins, nil
 }
 
+// lockCoins retrieves the number of coins of the user, locking the wallet until the transaction ends.
+func (s *service) lockCoins(tx *sql.Tx, userId int) (int, error) {
+	var coins int
+	err := tx.QueryRow("SELECT amount FROM coins WHERE user_id = $1 FOR UPDATE", userId).Scan(&coins)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, customErrors.ErrNotFound
+	}
+	if err != nil {
+		return 0, err
+	}
+	return coins, nil
+}
+
 // GetInventoryByUserID retrieves the inventory items by the given user ID.
 func (s *service) GetInventoryByUserID(userId int) ([]entities.InventoryItem, error) {
-	if inventoryItems, ok := s.cache.Get(strconv.Itoa(userId)); ok {
-		return inventoryItems.([]entities.InventoryItem), nil
+	if cached, ok := s.cache.Get(inventoryCacheKey(userId)); ok {
+		if inventoryItems, ok := cached.([]entities.InventoryItem); ok {
+			return inventoryItems, nil
+		}
 	}
 	var inventoryItems []entities.InventoryItem
-	rows, err := s.db.Query("SELECT item_type, quantity FROM inventory WHERE user_id = $1", userId)
-	if errors.Is(err, sql.ErrNoRows) {
-		return inventoryItems, nil
-	}
+	rows, err := s.reader(userId).Query("SELECT item_type, quantity FROM inventory WHERE user_id = $1", userId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var item entities.InventoryItem
 		err = rows.Scan(&item.ItemType, &item.Quantity)
@@ -165,179 +284,192 @@ func (s *service) GetInventoryByUserID(userId int) ([]entities.InventoryItem, er
 		}
 		inventoryItems = append(inventoryItems, item)
 	}
-	s.cache.Set(strconv.Itoa(userId), inventoryItems)
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	s.cache.Set(inventoryCacheKey(userId), inventoryItems)
 	return inventoryItems, nil
 }
 
 // GetTransactionsByUserID retrieves the transactions by the given user ID.
 func (s *service) GetTransactionsByUserID(userId int) ([]entities.Transaction, error) {
 	var transactions []entities.Transaction
-	rows, err := s.db.Query("SELECT from_user_id, to_user_id, amount FROM coin_transactions WHERE from_user_id = $1 OR to_user_id = $1", userId)
-	if errors.Is(err, sql.ErrNoRows) {
-		return nil, nil
-	}
+	rows, err := s.reader(userId).Query("SELECT COALESCE(from_user_id, 0), COALESCE(to_user_id, 0), amount, transaction_type, COALESCE(order_id, 0), message, COALESCE(actor_id, 0), created_at FROM coin_transactions WHERE from_user_id = $1 OR to_user_id = $1 ORDER BY id", userId)
 	if err != nil {
 		return nil, err
 	}
+	defer rows.Close()
 	for rows.Next() {
 		var transaction entities.Transaction
-		err = rows.Scan(&transaction.FromUserID, &transaction.ToUserID, &transaction.Amount)
+		err = rows.Scan(&transaction.FromUserID, &transaction.ToUserID, &transaction.Amount, &transaction.Type, &transaction.OrderID, &transaction.Message, &transaction.ActorID, &transaction.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
 		transactions = append(transactions, transaction)
 	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
 	return transactions, nil
 }
 
-// SendCoin sends coins from one user to another.
-func (s *service) SendCoin(fromUserID, toUserID, amount int) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
-	}
-	defer tx.Rollback()
-
-	fromUserCoins, err := s.GetCoinsByUserID(fromUserID)
-	if err != nil {
-		return err
-	}
-
-	if fromUserCoins < amount {
-		return customErrors.ErrNotEnoughCoins
-	}
-
-	toUserCoins, err := s.GetCoinsByUserID(toUserID)
+// GetShopItems retrieves the shop catalog.
+func (s *service) GetShopItems() ([]entities.ShopItem, error) {
+	var items []entities.ShopItem
+	rows, err := s.catalogReader().Query("SELECT " + shopItemColumns + " FROM shop ORDER BY item_type")
 	if err != nil {
-		return err
-	}
-
-	if err := s.UpdateCoins(fromUserID, fromUserCoins-amount); err != nil {
-		return err
-	}
-	if err := s.UpdateCoins(toUserID, toUserCoins+amount); err != nil {
-		return err
-	}
-
-	transaction := &entities.Transaction{
-		FromUserID: fromUserID,
-		ToUserID:   toUserID,
-		Amount:     amount,
+		return nil, err
 	}
-	if err := s.SaveTransaction(transaction); err != nil {
-		return err
+	defer rows.Close()
+	for rows.Next() {
+		item, err := scanShopItem(rows)
+		if err != nil {
+			return nil, err
+		}
+		items = append(items, *item)
 	}
-
-	if err := tx.Commit(); err != nil {
-		return err
+	if err := rows.Err(); err != nil {
+		return nil, err
 	}
-
-	return nil
+	return items, nil
 }
 
-// UpdateCoins updates the number of coins by the given user ID.
-func (s *service) UpdateCoins(userId, coins int) error {
-	_, err := s.db.Exec("UPDATE coins SET amount = $1 WHERE user_id = $2", coins, userId)
+// SendCoin sends coins from one user to another with an optional message.
+func (s *service) SendCoin(fromUserID, toUserID, amount int, message string) error {
+	err := s.inTx(func(tx *sql.Tx) error {
+		return s.transfer(tx, fromUserID, toUserID, amount, message)
+	})
 	if err != nil {
 		return err
 	}
+	s.pins.pin(s.clock.Now(), fromUserID, toUserID)
 	return nil
 }
 
-// SaveTransaction inserts a new transaction into the database.
-func (s *service) SaveTransaction(transaction *entities.Transaction) error {
-	_, err := s.db.Exec("INSERT INTO coin_transactions (from_user_id, to_user_id, amount, transaction_type, created_at) VALUES ($1, $2, $3, $4, $5)", transaction.FromUserID, transaction.ToUserID, transaction.Amount, "send", time.Now())
+// SendCoinBulk sends coins from one user to each recipient in one transaction, all or nothing.
+func (s *service) SendCoinBulk(fromUserID int, transfers []entities.Transfer) error {
+	userIds := []int{fromUserID}
+	total := 0
+	for _, t := range transfers {
+		userIds = append(userIds, t.ToUserID)
+		total += t.Amount
+	}
+	// Lock every wallet up front in a fixed order, transfer locks them again as a no-op.
+	slices.Sort(userIds)
+	userIds = slices.Compact(userIds)
+
+	err := s.inTx(func(tx *sql.Tx) error {
+		for _, userId := range userIds {
+			balance, err := s.lockCoins(tx, userId)
+			if err != nil {
+				return err
+			}
+			if userId == fromUserID && balance < total {
+				return customErrors.ErrNotEnoughCoins
+			}
+		}
+		for _, t := range transfers {
+			if err := s.transfer(tx, fromUserID, t.ToUserID, t.Amount, t.Message); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
 	if err != nil {
 		return err
 	}
+	s.pins.pin(s.clock.Now(), userIds...)
 	return nil
 }
 
-// BuyItem buys an item for the given user.
-func (s *service) BuyItem(userId int, itemType string) error {
-	tx, err := s.db.Begin()
-	if err != nil {
-		return err
+// transfer moves coins between two users within the transaction. Every check,
+// the wallet rules included, runs before anything is written.
+func (s *service) transfer(tx *sql.Tx, fromUserID, toUserID, amount int, message string) error {
+	if !entities.ValidAmount(amount) {
+		return customErrors.ErrInvalidAmount
 	}
-	defer tx.Rollback()
-
-	coins, err := s.GetCoinsByUserID(userId)
-	if err != nil {
-		return err
+	// Lock both wallets in a fixed order to avoid deadlocks between opposite transfers.
+	first, second := fromUserID, toUserID
+	if second < first {
+		first, second = second, first
+	}
+	coins := make(map[int]int, 2)
+	for _, userId := range []int{first, second} {
+		balance, err := s.lockCoins(tx, userId)
+		if err != nil {
+			return err
+		}
+		coins[userId] = balance
 	}
 
-	itemPrice, err := s.GetItemPrice(itemType)
-	if err != nil {
+	if err := checkActive(tx, fromUserID, toUserID); err != nil {
 		return err
 	}
-
-	if coins < itemPrice {
+	if coins[fromUserID] < amount {
 		return customErrors.ErrNotEnoughCoins
 	}
-
-	if err := s.UpdateCoins(userId, coins-itemPrice); err != nil {
+	if err := s.checkTransferPolicy(tx, fromUserID, toUserID, coins[toUserID], amount); err != nil {
 		return err
 	}
+	if !entities.CanCredit(coins[toUserID], amount) {
+		return customErrors.ErrBalanceOverflow
+	}
 
-	if err := s.AddItemToInventory(userId, itemType); err != nil {
+	if err := s.updateCoins(tx, fromUserID, coins[fromUserID]-amount); err != nil {
 		return err
 	}
-
-	if err := tx.Commit(); err != nil {
+	if err := s.updateCoins(tx, toUserID, coins[toUserID]+amount); err != nil {
 		return err
 	}
 
-	return nil
-}
-
-// GetItemPrice retrieves the price of the item by the given item type.
-func (s *service) GetItemPrice(itemType string) (int, error) {
-	var price int
-	row := s.db.QueryRow("SELECT price FROM shop WHERE item_type = $1", itemType)
-	err := row.Scan(&price)
-	if errors.Is(err, sql.ErrNoRows) {
-		return 0, customErrors.ErrNotFound
+	transaction := &entities.Transaction{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		Type:       entities.TransactionSend,
+		Message:    message,
 	}
-	if err != nil {
-		return 0, err
+	if err := s.saveTransaction(tx, transaction); err != nil {
+		return err
 	}
-	return price, nil
+	return s.saveEvent(tx, events.CoinsSent, events.CoinsSentPayload{
+		FromUserID: fromUserID,
+		ToUserID:   toUserID,
+		Amount:     amount,
+		Message:    message,
+	})
 }
 
-// AddItemToInventory adds an item to the inventory of the given user.
-func (s *service) AddItemToInventory(userId int, itemType string) error {
-	tx, err := s.db.Begin()
+// updateCoins updates the number of coins by the given user ID.
+func (s *service) updateCoins(q querier, userId, coins int) error {
+	_, err := q.Exec("UPDATE coins SET amount = $1 WHERE user_id = $2", coins, userId)
 	if err != nil {
 		return err
 	}
-	defer tx.Rollback()
-	var quantity int
-	err = s.db.QueryRow("SELECT quantity FROM inventory WHERE user_id = $1 AND item_type = $2", userId, itemType).Scan(&quantity)
+	return nil
+}
+
+// saveTransaction inserts a new transaction into the database.
+// Zero user, order and actor IDs are stored as NULL.
+func (s *service) saveTransaction(q querier, transaction *entities.Transaction) error {
+	_, err := q.Exec("INSERT INTO coin_transactions (from_user_id, to_user_id, amount, transaction_type, order_id, message, actor_id, created_at) VALUES (NULLIF($1, 0), NULLIF($2, 0), $3, $4, NULLIF($5, 0), $6, NULLIF($7, 0), $8)",
+		transaction.FromUserID, transaction.ToUserID, transaction.Amount, transaction.Type, transaction.OrderID, transaction.Message, transaction.ActorID, s.clock.Now())
 	if err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			_, err = s.db.Exec("INSERT INTO inventory (user_id, item_type, quantity) VALUES ($1, $2, 1)", userId, itemType)
-			if err != nil {
-				return err
-			}
-		} else {
-			return err
-		}
-	} else {
-		_, err = s.db.Exec("UPDATE inventory SET quantity = quantity + 1 WHERE user_id = $1 AND item_type = $2", userId, itemType)
-		if err != nil {
-			return err
-		}
-	}
-	s.cache.Delete(strconv.Itoa(userId))
-	if err = tx.Commit(); err != nil {
 		return err
 	}
 	return nil
 }
 
-// InitUserWallet initializes the user wallet with the initial amount of coins.
-func (s *service) InitUserWallet(userId int) error {
-	if _, err := s.db.Exec("INSERT INTO coins (user_id, amount) VALUES ($1, $2)", userId, INITIAL_COINS); err != nil {
+// addItemToInventory adds units of an item to the inventory of the given user.
+func (s *service) addItemToInventory(q querier, userId int, itemType string, quantity int) error {
+	_, err := q.Exec(`INSERT INTO inventory (user_id, item_type, quantity) VALUES ($1, $2, $3)
+		ON CONFLICT (user_id, item_type) DO UPDATE SET quantity = inventory.quantity + $3`, userId, itemType, quantity)
+	return err
+}
+
+// initUserWallet initializes the user wallet with the initial amount of coins.
+func (s *service) initUserWallet(q querier, userId int) error {
+	if _, err := q.Exec("INSERT INTO coins (user_id, amount) VALUES ($1, $2)", userId, s.wallet.InitialCoins); err != nil {
 		return err
 	}
 	return nil
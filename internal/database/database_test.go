@@ -1,12 +1,21 @@
 package database
 
 import (
+	"avitotech/internal/config"
 	"avitotech/internal/customErrors"
 	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"avitotech/pkg/clock"
+	"avitotech/pkg/imcache"
 	"context"
+	"database/sql"
 	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
@@ -15,6 +24,24 @@ import (
 	"github.com/testcontainers/testcontainers-go/wait"
 )
 
+var (
+	testConfig *config.Config
+	testDB     *sql.DB
+)
+
+// requireDB skips the test when no Postgres container is running.
+func requireDB(t *testing.T) {
+	t.Helper()
+	if testDB == nil {
+		t.Skip("Postgres container is not available")
+	}
+}
+
+func newTestService(t *testing.T) Service {
+	requireDB(t)
+	return New(testDB, imcache.NewInMemoryCache(5*time.Minute), testConfig, clock.NewRealClock(), slog.Default())
+}
+
 func mustStartPostgresContainer() (func(context.Context, ...testcontainers.TerminateOption) error, error) {
 	var (
 		dbName = "database"
@@ -28,7 +55,6 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		postgres.WithDatabase(dbName),
 		postgres.WithUsername(dbUser),
 		postgres.WithPassword(dbPwd),
-		postgres.WithInitScripts("test_init.sql"),
 		testcontainers.WithWaitStrategy(
 			wait.ForLog("database system is ready to accept connections").
 				WithOccurrence(2).
@@ -39,9 +65,10 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return nil, err
 	}
 
-	database = dbName
-	password = dbPwd
-	username = dbUser
+	testConfig = config.Default()
+	testConfig.Database.Name = dbName
+	testConfig.Database.Password = dbPwd
+	testConfig.Database.User = dbUser
 
 	dbHost, err := dbContainer.Host(context.Background())
 	if err != nil {
@@ -53,16 +80,42 @@ func mustStartPostgresContainer() (func(context.Context, ...testcontainers.Termi
 		return dbContainer.Terminate, err
 	}
 
-	host = dbHost
-	port = dbPort.Port()
-	schema = "public"
+	testConfig.Database.Host = dbHost
+	testConfig.Database.Port = dbPort.Int()
+	testConfig.Database.Schema = "public"
+
+	fmt.Println("host:", dbHost, "port:", dbPort.Port())
 
-	fmt.Println("host:", host, "port:", port)
+	testDB, err = Open(context.Background(), testConfig.Database)
+	if err != nil {
+		return dbContainer.Terminate, err
+	}
 
-	return dbContainer.Terminate, err
+	return dbContainer.Terminate, MigrateUp(context.Background(), testDB)
+}
+
+// dockerAvailable reports whether testcontainers can reach a Docker daemon,
+// testcontainers panics when it finds none.
+func dockerAvailable() (ok bool) {
+	defer func() {
+		if recover() != nil {
+			ok = false
+		}
+	}()
+	provider, err := testcontainers.NewDockerProvider()
+	if err != nil {
+		return false
+	}
+	defer provider.Close()
+	return provider.Health(context.Background()) == nil
 }
 
 func TestMain(m *testing.M) {
+	if !dockerAvailable() {
+		fmt.Println("Docker is not available, skipping tests that need Postgres")
+		m.Run()
+		return
+	}
 	teardown, err := mustStartPostgresContainer()
 	fmt.Println("Started postgres container")
 	if err != nil {
@@ -71,6 +124,9 @@ func TestMain(m *testing.M) {
 
 	m.Run()
 
+	if testDB != nil {
+		testDB.Close()
+	}
 	if teardown != nil && teardown(context.Background()) != nil {
 		log.Fatalf("could not teardown postgres container: %v", err)
 	}
@@ -78,32 +134,58 @@ func TestMain(m *testing.M) {
 }
 
 func TestNew(t *testing.T) {
-	srv := New()
+	srv := newTestService(t)
 	if srv == nil {
 		t.Fatal("New() returned nil")
 	}
 }
 
+func TestCheckSchemaVersion(t *testing.T) {
+	requireDB(t)
+	ctx := context.Background()
+	if err := CheckSchemaVersion(ctx, testDB); err != nil {
+		t.Fatalf("expected CheckSchemaVersion() to return nil after MigrateUp, got %v", err)
+	}
+
+	// Forget a migration in the middle, as if it had been merged after a newer one was applied.
+	var version int64
+	if err := testDB.QueryRow("SELECT version_id FROM goose_db_version WHERE version_id > 0 ORDER BY version_id LIMIT 1 OFFSET 1").Scan(&version); err != nil {
+		t.Fatalf("select migration version: %v", err)
+	}
+	if _, err := testDB.Exec("DELETE FROM goose_db_version WHERE version_id = $1", version); err != nil {
+		t.Fatalf("delete migration version: %v", err)
+	}
+	defer func() {
+		if _, err := testDB.Exec("INSERT INTO goose_db_version (version_id, is_applied) VALUES ($1, true)", version); err != nil {
+			t.Fatalf("restore migration version: %v", err)
+		}
+	}()
+
+	if err := CheckSchemaVersion(ctx, testDB); !errors.Is(err, ErrSchemaOutdated) {
+		t.Fatalf("expected CheckSchemaVersion() with a missing migration to return ErrSchemaOutdated, got %v", err)
+	}
+}
+
 func TestAddUser(t *testing.T) {
-	srv := New()
-	if err := srv.AddUser(&entities.User{Username: "user", Password: "password"}); err != nil {
+	srv := newTestService(t)
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "user", Password: "password"}); err != nil {
 		t.Fatalf("expected AddUser() to return nil, got %v", err)
 	}
-	if err := srv.AddUser(&entities.User{Username: "user", Password: "password"}); err == nil {
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "user", Password: "password"}); err == nil {
 		t.Fatalf("expected AddUser() with duplicate username to return duplicate error, got %v", err)
 	}
 
 }
 
 func TestGetUserByName(t *testing.T) {
-	srv := New()
+	srv := newTestService(t)
 	user, err := srv.GetUserByName("unknownuser")
 
 	if user != nil || err != nil {
 		t.Fatalf("expected GetUserByName() with unknown user to return (nil, nil), got (%v, %v)", user, err)
 	}
 
-	err = srv.AddUser(&entities.User{Username: "knownuser", Password: "password"})
+	err = srv.AddUser(context.Background(), &entities.User{Username: "knownuser", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
@@ -116,9 +198,41 @@ func TestGetUserByName(t *testing.T) {
 
 }
 
+func TestCacheSharedWithOtherServices(t *testing.T) {
+	requireDB(t)
+	cache := imcache.NewInMemoryCache(5 * time.Minute)
+	defer cache.Close()
+	srv := New(testDB, cache, testConfig, clock.NewRealClock(), slog.Default())
+
+	// Other services store their own values in the same cache under keys users may pick as names.
+	cache.Set("stats:leaderboard:week:10", "leaderboard")
+	cache.Set("stats:me:1", "stats")
+
+	user, err := srv.GetUserByName("stats:leaderboard:week:10")
+	if user != nil || err != nil {
+		t.Fatalf("expected an unknown user named after a foreign key to return (nil, nil), got (%v, %v)", user, err)
+	}
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "stats:me:1", Password: "password"}); err != nil {
+		t.Fatalf("Unexpected error while adding user: %v", err)
+	}
+	user, err = srv.GetUserByName("stats:me:1")
+	if user == nil || err != nil {
+		t.Fatalf("expected GetUserByName() to return the user, got (%v, %v)", user, err)
+	}
+	cache.Set("stats:me:1", "stats")
+	if user, err = srv.GetUserByName("stats:me:1"); user == nil || err != nil {
+		t.Fatalf("expected a foreign value to leave the cached user intact, got (%v, %v)", user, err)
+	}
+
+	cache.Set(inventoryCacheKey(user.ID), "not an inventory")
+	if _, err := srv.GetInventoryByUserID(user.ID); err != nil {
+		t.Fatalf("expected a mistyped cache entry to be reloaded, got %v", err)
+	}
+}
+
 func TestGetUserNameById(t *testing.T) {
-	srv := New()
-	err := srv.AddUser(&entities.User{Username: "knownuser", Password: "password"})
+	srv := newTestService(t)
+	err := srv.AddUser(context.Background(), &entities.User{Username: "knownuser", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
@@ -126,17 +240,17 @@ func TestGetUserNameById(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected error while getting user: %v", err)
 	}
-	if username := srv.GetUserNameById(0); username != "<unknown>" {
+	if username := srv.GetUserNameById(context.Background(), 0); username != "<unknown>" {
 		t.Fatalf("expected GetUserNameById() to return <unknown>, got %v", username)
 	}
-	if username = srv.GetUserNameById(user.ID); username != "knownuser" {
+	if username := srv.GetUserNameById(context.Background(), user.ID); username != "knownuser" {
 		t.Fatalf("expected GetUserNameById() to return knownuser, got %v", username)
 	}
 }
 
 func TestGetCoinsByUserID(t *testing.T) {
-	srv := New()
-	err := srv.AddUser(&entities.User{Username: "knownuser", Password: "password"})
+	srv := newTestService(t)
+	err := srv.AddUser(context.Background(), &entities.User{Username: "knownuser", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
@@ -148,8 +262,8 @@ func TestGetCoinsByUserID(t *testing.T) {
 	if err != nil {
 		t.Fatalf("expected GetCoinsByUserID() don't return error, got %v", err)
 	}
-	if coins != INITIAL_COINS {
-		t.Fatalf("expected GetCoinsByUserID() to return %v, got %v", INITIAL_COINS, coins)
+	if coins != testConfig.Wallet.InitialCoins {
+		t.Fatalf("expected GetCoinsByUserID() to return %v, got %v", testConfig.Wallet.InitialCoins, coins)
 	}
 	coins, err = srv.GetCoinsByUserID(-1)
 	if err != nil {
@@ -161,8 +275,8 @@ func TestGetCoinsByUserID(t *testing.T) {
 }
 
 func TestGetInventoryByUserID(t *testing.T) {
-	srv := New()
-	err := srv.AddUser(&entities.User{Username: "knownuser", Password: "password"})
+	srv := newTestService(t)
+	err := srv.AddUser(context.Background(), &entities.User{Username: "knownuser", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
@@ -177,7 +291,7 @@ func TestGetInventoryByUserID(t *testing.T) {
 	if len(inventory) != 0 {
 		t.Fatalf("expected GetInventoryByUserID() to return empty inventory, got %v", inventory)
 	}
-	err = srv.BuyItem(user.ID, "hoody")
+	_, err = srv.BuyItem(user.ID, "hoody", 1)
 	if err != nil {
 		t.Fatal("Unexpected error while buying item")
 	}
@@ -191,12 +305,12 @@ func TestGetInventoryByUserID(t *testing.T) {
 }
 
 func TestGetTransactionsByUserID(t *testing.T) {
-	srv := New()
-	err := srv.AddUser(&entities.User{Username: "knownuser1", Password: "password"})
+	srv := newTestService(t)
+	err := srv.AddUser(context.Background(), &entities.User{Username: "knownuser1", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
-	err = srv.AddUser(&entities.User{Username: "knownuser2", Password: "password"})
+	err = srv.AddUser(context.Background(), &entities.User{Username: "knownuser2", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
@@ -211,10 +325,10 @@ func TestGetTransactionsByUserID(t *testing.T) {
 	if transactions, err := srv.GetTransactionsByUserID(user1.ID); err != nil || len(transactions) != 0 {
 		t.Fatalf("expected GetTransactionsByUserID() to return %v, got %v", nil, transactions)
 	}
-	if err := srv.SendCoin(user1.ID, user2.ID, 1200); !errors.Is(err, customErrors.ErrNotEnoughCoins) {
+	if err := srv.SendCoin(user1.ID, user2.ID, 1200, ""); !errors.Is(err, customErrors.ErrNotEnoughCoins) {
 		t.Fatalf("expected SendCoin() to return ErrNotEnoughCoins, got %v", err)
 	}
-	if err := srv.SendCoin(user1.ID, user2.ID, 500); err != nil {
+	if err := srv.SendCoin(user1.ID, user2.ID, 500, "for lunch"); err != nil {
 		t.Fatalf("expected SendCoin() to return nil, got %v", err)
 	}
 	if coins, err := srv.GetCoinsByUserID(user1.ID); err != nil || coins != 500 {
@@ -249,11 +363,672 @@ func TestGetTransactionsByUserID(t *testing.T) {
 	if transactions[0].Amount != 500 {
 		t.Fatalf("expected GetTransactionsByUserID() to return transaction amount %v, got %v", 500, transactions[0].Amount)
 	}
+	if transactions[0].Message != "for lunch" {
+		t.Fatalf("expected GetTransactionsByUserID() to return transaction message %q, got %q", "for lunch", transactions[0].Message)
+	}
+}
+
+func TestAmountChecks(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"checked1", "checked2"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	user1, user2 := ids[0], ids[1]
+
+	for _, amount := range []int{-500, 0, entities.MaxAmount + 1} {
+		if err := srv.SendCoin(user1, user2, amount, ""); !errors.Is(err, customErrors.ErrInvalidAmount) {
+			t.Fatalf("expected SendCoin(%d) to return ErrInvalidAmount, got %v", amount, err)
+		}
+	}
+	if _, err := srv.MintCoins(0, user2, entities.MaxBalance-testConfig.Wallet.InitialCoins, ""); err != nil {
+		t.Fatalf("expected MintCoins() up to the max balance to succeed, got %v", err)
+	}
+	if err := srv.SendCoin(user1, user2, 1, ""); !errors.Is(err, customErrors.ErrBalanceOverflow) {
+		t.Fatalf("expected SendCoin() to a full wallet to return ErrBalanceOverflow, got %v", err)
+	}
+	if _, err := testDB.Exec("UPDATE coins SET amount = -1 WHERE user_id = $1", user1); err == nil {
+		t.Fatalf("expected the coins table to reject a negative balance")
+	}
+	if _, err := testDB.Exec("INSERT INTO coin_transactions (from_user_id, to_user_id, amount, transaction_type) VALUES ($1, $2, -1, 'send')", user1, user2); err == nil {
+		t.Fatalf("expected the coin_transactions table to reject a negative amount")
+	}
+}
+
+func TestWalletStats(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"stats1", "stats2", "stats3"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	user1, user2, user3 := ids[0], ids[1], ids[2]
+
+	for _, transfer := range []struct{ from, to, amount int }{{user1, user2, 100}, {user1, user2, 50}, {user3, user1, 30}} {
+		if err := srv.SendCoin(transfer.from, transfer.to, transfer.amount, ""); err != nil {
+			t.Fatalf("Unexpected error while sending coins: %v", err)
+		}
+	}
+	spent := 0
+	for _, item := range []string{"cup", "cup", "pen"} {
+		order, err := srv.BuyItem(user1, item, 1)
+		if err != nil {
+			t.Fatalf("Unexpected error while buying %s: %v", item, err)
+		}
+		spent += order.Price * order.Quantity
+	}
+
+	stats, err := srv.GetWalletStats(user1)
+	if err != nil {
+		t.Fatalf("expected GetWalletStats() to return nil, got %v", err)
+	}
+	want := entities.WalletStats{Sent: 150, Received: 30, Spent: spent, Counterparties: 2, FavouriteItem: "cup"}
+	if *stats != want {
+		t.Fatalf("expected GetWalletStats() to return %+v, got %+v", want, *stats)
+	}
+
+	receivers, err := srv.GetTopReceivers(time.Now().Add(-time.Hour), 500)
+	if err != nil {
+		t.Fatalf("expected GetTopReceivers() to return nil, got %v", err)
+	}
+	found := false
+	for _, entry := range receivers {
+		if entry.UserID == user2 {
+			found = entry.Total == 150 && entry.Count == 2 && entry.Username == "stats2"
+		}
+	}
+	if !found {
+		t.Fatalf("expected GetTopReceivers() to rank stats2 with 150 coins in 2 transfers, got %+v", receivers)
+	}
+
+	if err := srv.EraseUser(user2); err != nil {
+		t.Fatalf("expected EraseUser() to return nil, got %v", err)
+	}
+	if err := srv.DeactivateUser(user1); err != nil {
+		t.Fatalf("expected DeactivateUser() to return nil, got %v", err)
+	}
+	since := time.Now().Add(-time.Hour)
+	for name, top := range map[string]func(time.Time, int) ([]entities.LeaderboardEntry, error){
+		"GetTopSenders": srv.GetTopSenders, "GetTopReceivers": srv.GetTopReceivers, "GetTopSpenders": srv.GetTopSpenders,
+	} {
+		entries, err := top(since, 500)
+		if err != nil || slices.ContainsFunc(entries, func(entry entities.LeaderboardEntry) bool { return entry.UserID == user1 || entry.UserID == user2 }) {
+			t.Fatalf("expected %s() to leave out deactivated and erased users, got %+v, %v", name, entries, err)
+		}
+	}
+}
+
+func TestUserDirectory(t *testing.T) {
+	srv := newTestService(t)
+	for _, username := range []string{"dir_alice", "dir_alina", "dirxbob"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+	}
+
+	users, err := srv.SearchUsers("dir_ali", 10)
+	if err != nil {
+		t.Fatalf("expected SearchUsers() to return nil, got %v", err)
+	}
+	if len(users) < 2 || users[0].Username != "dir_alice" || users[1].Username != "dir_alina" {
+		t.Fatalf("expected SearchUsers() to return the prefix matches first, got %+v", users)
+	}
+	for _, user := range users {
+		if user.Username == "dirxbob" {
+			t.Fatalf("expected SearchUsers() to treat _ literally, got %+v", users)
+		}
+	}
+	if users, err = srv.SearchUsers("dir_alise", 10); err != nil || len(users) == 0 || users[0].Username != "dir_alice" {
+		t.Fatalf("expected SearchUsers() to find dir_alice by similarity, got %+v, %v", users, err)
+	}
+
+	alina, err := srv.GetUserByName("dir_alina")
+	if err != nil {
+		t.Fatalf("Unexpected error while getting user: %v", err)
+	}
+	if err := srv.DeactivateUser(alina.ID); err != nil {
+		t.Fatalf("expected DeactivateUser() to return nil, got %v", err)
+	}
+	if users, err = srv.SearchUsers("dir_ali", 10); err != nil || slices.ContainsFunc(users, func(user entities.User) bool { return user.ID == alina.ID }) {
+		t.Fatalf("expected SearchUsers() to leave out deactivated users, got %+v, %v", users, err)
+	}
+
+	alice, err := srv.GetUserByName("dir_alice")
+	if err != nil {
+		t.Fatalf("Unexpected error while getting user: %v", err)
+	}
+	if _, err := srv.UpdateUserProfile(alice.ID, "Alice", "Platform"); err != nil {
+		t.Fatalf("expected UpdateUserProfile() to return nil, got %v", err)
+	}
+	if alice, err = srv.GetUserByName("dir_alice"); err != nil || alice.DisplayName != "Alice" || alice.Department != "Platform" {
+		t.Fatalf("expected GetUserByName() to return the updated profile, got %+v, %v", alice, err)
+	}
+	if _, err := srv.UpdateUserProfile(-1, "", ""); !errors.Is(err, customErrors.ErrNotFound) {
+		t.Fatalf("expected UpdateUserProfile() of an unknown user to return ErrNotFound, got %v", err)
+	}
+}
+
+func TestDeactivateAndEraseUser(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"leaver", "stayer"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	leaver, stayer := ids[0], ids[1]
+	if err := srv.SendCoin(leaver, stayer, 100, "thanks, it was personal"); err != nil {
+		t.Fatalf("Unexpected error while sending coins: %v", err)
+	}
+
+	if err := srv.DeactivateUser(leaver); err != nil {
+		t.Fatalf("expected DeactivateUser() to return nil, got %v", err)
+	}
+	if user, err := srv.GetUserByName("leaver"); err != nil || !user.Deactivated() {
+		t.Fatalf("expected GetUserByName() to return a deactivated user, got %+v, %v", user, err)
+	}
+	if err := srv.SendCoin(stayer, leaver, 10, ""); !errors.Is(err, customErrors.ErrUserDeactivated) {
+		t.Fatalf("expected SendCoin() to a deactivated user to return ErrUserDeactivated, got %v", err)
+	}
+	if active, err := srv.IsUserActive(leaver); err != nil || active {
+		t.Fatalf("expected IsUserActive() to return false after the deactivation, got (%v, %v)", active, err)
+	}
+	// A token issued before the deactivation must not be able to spend or receive coins.
+	if _, err := srv.BuyItem(leaver, "pen", 1); !errors.Is(err, customErrors.ErrUserDeactivated) {
+		t.Fatalf("expected BuyItem() of a deactivated user to return ErrUserDeactivated, got %v", err)
+	}
+	if _, err := srv.MintCoins(stayer, leaver, 10, "bonus"); !errors.Is(err, customErrors.ErrUserDeactivated) {
+		t.Fatalf("expected MintCoins() to a deactivated user to return ErrUserDeactivated, got %v", err)
+	}
+	if err := srv.AwardCoins(stayer, []entities.Transfer{{ToUserID: leaver, Amount: 10}}); !errors.Is(err, customErrors.ErrUserDeactivated) {
+		t.Fatalf("expected AwardCoins() to a deactivated user to return ErrUserDeactivated, got %v", err)
+	}
+	if err := srv.ReactivateUser(leaver); err != nil {
+		t.Fatalf("expected ReactivateUser() to return nil, got %v", err)
+	}
+	if active, err := srv.IsUserActive(leaver); err != nil || !active {
+		t.Fatalf("expected IsUserActive() to return true after the reactivation, got (%v, %v)", active, err)
+	}
+	if err := srv.SendCoin(stayer, leaver, 10, ""); err != nil {
+		t.Fatalf("expected SendCoin() to a reactivated user to return nil, got %v", err)
+	}
+
+	var subscription int
+	err := testDB.QueryRow(`INSERT INTO webhook_subscriptions (url, event_types, secret) VALUES ('https://hooks.example', '["coins.sent"]', 'secret') RETURNING id`).Scan(&subscription)
+	if err != nil {
+		t.Fatalf("insert subscription: %v", err)
+	}
+	delivered := fmt.Sprintf(`{"id": 1, "type": "coins.sent", "payload": {"fromUserId": %d, "toUserId": %d, "amount": 100, "message": "thanks, it was personal"}}`, leaver, stayer)
+	if _, err := testDB.Exec("INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, success) VALUES ($1, 1, 'coins.sent', $2, TRUE)",
+		subscription, delivered); err != nil {
+		t.Fatalf("insert delivery: %v", err)
+	}
+
+	if err := srv.EraseUser(leaver); err != nil {
+		t.Fatalf("expected EraseUser() to return nil, got %v", err)
+	}
+	var leaks int
+	err = testDB.QueryRow(`SELECT (SELECT COUNT(*) FROM outbox_events WHERE payload::text LIKE '%leaver%' OR payload::text LIKE '%it was personal%')
+		+ (SELECT COUNT(*) FROM webhook_deliveries WHERE payload::text LIKE '%it was personal%')`).Scan(&leaks)
+	if err != nil || leaks != 0 {
+		t.Fatalf("expected EraseUser() to scrub the event payloads, got %d leaks, %v", leaks, err)
+	}
+	if user, err := srv.GetUserByName("leaver"); err != nil || user != nil {
+		t.Fatalf("expected the erased username to be gone, got %+v, %v", user, err)
+	}
+	if active, err := srv.IsUserActive(leaver); err != nil || active {
+		t.Fatalf("expected IsUserActive() to return false for an erased user, got (%v, %v)", active, err)
+	}
+	erased, err := srv.GetUserByID(leaver)
+	if err != nil || erased.Username != entities.ErasedUsername(leaver) || erased.ErasedAt == nil || !erased.Deactivated() || erased.Password != "" {
+		t.Fatalf("expected EraseUser() to anonymize the user, got %+v, %v", erased, err)
+	}
+	transactions, err := srv.GetTransactionsByUserID(stayer)
+	if err != nil {
+		t.Fatalf("Unexpected error while getting transactions: %v", err)
+	}
+	balance := 0
+	for _, transaction := range transactions {
+		if transaction.FromUserID == leaver && transaction.Message != "" {
+			t.Fatalf("expected EraseUser() to clear the messages of the user, got %q", transaction.Message)
+		}
+		if transaction.ToUserID == stayer {
+			balance += transaction.Amount
+		} else {
+			balance -= transaction.Amount
+		}
+	}
+	if coins, err := srv.GetCoinsByUserID(stayer); err != nil || coins != testConfig.Wallet.InitialCoins+balance {
+		t.Fatalf("expected the ledger to explain a balance of %d, got %d, %v", testConfig.Wallet.InitialCoins+balance, coins, err)
+	}
+}
+
+func TestSendCoinBulk(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"lead", "member1", "member2"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	lead, member1, member2 := ids[0], ids[1], ids[2]
+
+	tooMuch := []entities.Transfer{{ToUserID: member1, Amount: 600}, {ToUserID: member2, Amount: 600}}
+	if err := srv.SendCoinBulk(lead, tooMuch); !errors.Is(err, customErrors.ErrNotEnoughCoins) {
+		t.Fatalf("expected SendCoinBulk() to return ErrNotEnoughCoins, got %v", err)
+	}
+	if coins, err := srv.GetCoinsByUserID(member1); err != nil || coins != 1000 {
+		t.Fatalf("expected a rejected batch to send nothing, got %v coins", coins)
+	}
+
+	payout := []entities.Transfer{{ToUserID: member1, Amount: 300, Message: "bonus"}, {ToUserID: member2, Amount: 200, Message: "bonus"}}
+	if err := srv.SendCoinBulk(lead, payout); err != nil {
+		t.Fatalf("expected SendCoinBulk() to return nil, got %v", err)
+	}
+	for userId, want := range map[int]int{lead: 500, member1: 1300, member2: 1200} {
+		if coins, err := srv.GetCoinsByUserID(userId); err != nil || coins != want {
+			t.Fatalf("expected GetCoinsByUserID(%v) to return %v, got %v", userId, want, coins)
+		}
+	}
+	if transactions, err := srv.GetTransactionsByUserID(lead); err != nil || len(transactions) != 2 {
+		t.Fatalf("expected a transaction per recipient, got %v", transactions)
+	}
+}
+
+// TestWalletRules runs concurrent requests against the wallet rules, which are checked under the wallet locks.
+func TestWalletRules(t *testing.T) {
+	requireDB(t)
+	cfg := *testConfig
+	cfg.Wallet.MaxBalance = 1200
+	cfg.Wallet.DailyTransferLimit = 100
+	cfg.Wallet.DailyPurchaseBudget = 100
+	srv := New(testDB, imcache.NewInMemoryCache(5*time.Minute), &cfg, clock.NewRealClock(), slog.Default())
+	var ids []int
+	for _, username := range []string{"rulesender", "rulereceiver", "rulewhale"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	sender, receiver, whale := ids[0], ids[1], ids[2]
+
+	var wg sync.WaitGroup
+	results := make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- srv.SendCoin(sender, receiver, 20, "")
+		}()
+	}
+	wg.Wait()
+	close(results)
+	sent := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			sent++
+		case !errors.Is(err, customErrors.ErrDailyTransferLimit):
+			t.Fatalf("expected SendCoin() to return ErrDailyTransferLimit, got %v", err)
+		}
+	}
+	if sent != 5 {
+		t.Fatalf("expected 5 transfers to fit the daily limit, got %d", sent)
+	}
+
+	if err := srv.SendCoin(whale, receiver, 101, ""); !errors.Is(err, customErrors.ErrMaxBalance) {
+		t.Fatalf("expected SendCoin() above the max balance to return ErrMaxBalance, got %v", err)
+	}
+
+	results = make(chan error, 10)
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := srv.BuyItem(whale, "cup", 1)
+			results <- err
+		}()
+	}
+	wg.Wait()
+	close(results)
+	bought := 0
+	for err := range results {
+		switch {
+		case err == nil:
+			bought++
+		case !errors.Is(err, customErrors.ErrDailyPurchaseBudget):
+			t.Fatalf("expected BuyItem() to return ErrDailyPurchaseBudget, got %v", err)
+		}
+	}
+	if bought != 5 {
+		t.Fatalf("expected 5 cups to fit the daily budget, got %d", bought)
+	}
+
+	// Scheduled runs go through the same checks, a violation is recorded as a failed run.
+	due := time.Now().UTC().Add(-time.Minute).Truncate(time.Microsecond)
+	scheduled := &entities.ScheduledTransfer{FromUserID: sender, ToUserID: whale, Amount: 10, RunAt: &due, NextRunAt: &due}
+	if err := srv.CreateScheduledTransfer(scheduled); err != nil {
+		t.Fatalf("expected CreateScheduledTransfer() to return nil, got %v", err)
+	}
+	run, err := srv.ExecuteScheduledTransfer(scheduled.ID, due, nil, 3)
+	if err != nil || run == nil || run.Success || !strings.Contains(run.Error, customErrors.ErrDailyTransferLimit.Error()) {
+		t.Fatalf("expected the scheduled run to fail on the daily limit, got (%v, %v)", run, err)
+	}
+
+	// Refunds and admin credits are capped like transfers.
+	receiverCoins, err := srv.GetCoinsByUserID(receiver)
+	if err != nil {
+		t.Fatalf("Unexpected error while getting coins: %v", err)
+	}
+	if _, err := srv.MintCoins(whale, receiver, cfg.Wallet.MaxBalance-receiverCoins+1, "bonus"); !errors.Is(err, customErrors.ErrMaxBalance) {
+		t.Fatalf("expected MintCoins() above the max balance to return ErrMaxBalance, got %v", err)
+	}
+	if err := srv.AwardCoins(whale, []entities.Transfer{{ToUserID: receiver, Amount: cfg.Wallet.MaxBalance - receiverCoins + 1}}); !errors.Is(err, customErrors.ErrMaxBalance) {
+		t.Fatalf("expected AwardCoins() above the max balance to return ErrMaxBalance, got %v", err)
+	}
+	if balance, err := srv.MintCoins(whale, receiver, cfg.Wallet.MaxBalance-receiverCoins, "bonus"); err != nil || balance != cfg.Wallet.MaxBalance {
+		t.Fatalf("expected MintCoins() up to the max balance to return %d, got (%d, %v)", cfg.Wallet.MaxBalance, balance, err)
+	}
+	orders, err := srv.GetOrdersByUserID(whale, "", 1)
+	if err != nil || len(orders) != 1 {
+		t.Fatalf("expected GetOrdersByUserID() to return an order, got (%v, %v)", orders, err)
+	}
+	if _, err := testDB.Exec("UPDATE coins SET amount = $1 WHERE user_id = $2", cfg.Wallet.MaxBalance-orders[0].Price+1, whale); err != nil {
+		t.Fatalf("set coins: %v", err)
+	}
+	if _, err := srv.RefundOrder(orders[0].ID, time.Time{}); !errors.Is(err, customErrors.ErrMaxBalance) {
+		t.Fatalf("expected RefundOrder() above the max balance to return ErrMaxBalance, got %v", err)
+	}
+}
+
+func TestAdjustCoins(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"hr", "employee1", "employee2"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	admin, employee1, employee2 := ids[0], ids[1], ids[2]
+
+	if coins, err := srv.MintCoins(admin, employee1, 250, "hackathon"); err != nil || coins != 1250 {
+		t.Fatalf("expected MintCoins() to return 1250, got (%v, %v)", coins, err)
+	}
+	if _, err := srv.DebitCoins(admin, employee1, 5000, "mistake"); !errors.Is(err, customErrors.ErrNotEnoughCoins) {
+		t.Fatalf("expected DebitCoins() to return ErrNotEnoughCoins, got %v", err)
+	}
+	if coins, err := srv.DebitCoins(admin, employee1, 50, "mistake"); err != nil || coins != 1200 {
+		t.Fatalf("expected DebitCoins() to return 1200, got (%v, %v)", coins, err)
+	}
+	awards := []entities.Transfer{{ToUserID: employee1, Amount: 100}, {ToUserID: employee2, Amount: 300, Message: "q3"}}
+	if err := srv.AwardCoins(admin, awards); err != nil {
+		t.Fatalf("expected AwardCoins() to return nil, got %v", err)
+	}
+	if coins, err := srv.GetCoinsByUserID(employee2); err != nil || coins != 1300 {
+		t.Fatalf("expected GetCoinsByUserID() to return 1300, got %v", coins)
+	}
+
+	transactions, err := srv.GetTransactionsByUserID(employee1)
+	if err != nil {
+		t.Fatalf("expected GetTransactionsByUserID() not return error, got %v", err)
+	}
+	var types []string
+	for _, transaction := range transactions {
+		if transaction.ActorID != admin {
+			t.Fatalf("expected the ledger entry to record the admin, got %v", transaction.ActorID)
+		}
+		types = append(types, transaction.Type)
+	}
+	slices.Sort(types)
+	if want := []string{entities.TransactionAward, entities.TransactionDebit, entities.TransactionMint}; !slices.Equal(types, want) {
+		t.Fatalf("expected ledger entries %v, got %v", want, types)
+	}
+}
+
+func TestAuditLog(t *testing.T) {
+	srv := newTestService(t)
+	for i, action := range []string{entities.AuditLogin, entities.AuditTransfer, entities.AuditLogin} {
+		entry := &entities.AuditEntry{Action: action, Target: fmt.Sprintf("audited%d", i), IP: "127.0.0.1", Details: []byte(`{"amount": 10}`)}
+		if err := srv.AppendAuditEntry(entry); err != nil {
+			t.Fatalf("expected AppendAuditEntry() to return nil, got %v", err)
+		}
+		if entry.ID == 0 || entry.Hash != entry.ComputeHash() {
+			t.Fatalf("expected AppendAuditEntry() to chain the entry, got %+v", entry)
+		}
+	}
+
+	entries, err := srv.GetAuditEntries(entities.AuditFilter{Action: entities.AuditTransfer, Target: "audited1"}, 10)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected GetAuditEntries() to return the transfer, got (%v, %v)", entries, err)
+	}
+	if string(entries[0].Details) != `{"amount": 10}` {
+		t.Fatalf("expected details to be stored verbatim, got %s", entries[0].Details)
+	}
+
+	if checked, brokenAt, err := srv.VerifyAuditLog(); err != nil || brokenAt != 0 || checked < 3 {
+		t.Fatalf("expected VerifyAuditLog() to accept the chain, got (%v, %v, %v)", checked, brokenAt, err)
+	}
+	if _, err := testDB.Exec("UPDATE audit_log SET target = 'forged' WHERE id = $1", entries[0].ID); err == nil {
+		t.Fatalf("expected the audit log to reject updates")
+	}
+	if _, err := testDB.Exec("DELETE FROM audit_log WHERE id = $1", entries[0].ID); err == nil {
+		t.Fatalf("expected the audit log to reject deletes")
+	}
+}
+
+func TestCoinRequests(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"requester", "payer"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	requester, payer := ids[0], ids[1]
+
+	declined := &entities.CoinRequest{RequesterID: requester, PayerID: payer, Amount: 100}
+	if err := srv.CreateCoinRequest(declined); err != nil {
+		t.Fatalf("expected CreateCoinRequest() to return nil, got %v", err)
+	}
+	if _, err := srv.ResolveCoinRequest(declined.ID, requester, true); !errors.Is(err, customErrors.ErrNotFound) {
+		t.Fatalf("expected only the payer to resolve a request, got %v", err)
+	}
+	if request, err := srv.ResolveCoinRequest(declined.ID, payer, false); err != nil || request.Status != entities.CoinRequestDeclined {
+		t.Fatalf("expected ResolveCoinRequest() to decline the request, got (%v, %v)", request, err)
+	}
+
+	approved := &entities.CoinRequest{RequesterID: requester, PayerID: payer, Amount: 300, Message: "team lunch"}
+	if err := srv.CreateCoinRequest(approved); err != nil {
+		t.Fatalf("expected CreateCoinRequest() to return nil, got %v", err)
+	}
+	if request, err := srv.ResolveCoinRequest(approved.ID, payer, true); err != nil || request.Status != entities.CoinRequestApproved {
+		t.Fatalf("expected ResolveCoinRequest() to approve the request, got (%v, %v)", request, err)
+	}
+	if _, err := srv.ResolveCoinRequest(approved.ID, payer, true); !errors.Is(err, customErrors.ErrRequestResolved) {
+		t.Fatalf("expected a resolved request not to be paid twice, got %v", err)
+	}
+	if coins, err := srv.GetCoinsByUserID(requester); err != nil || coins != testConfig.Wallet.InitialCoins+300 {
+		t.Fatalf("expected GetCoinsByUserID() to return %v, got %v", testConfig.Wallet.InitialCoins+300, coins)
+	}
+	requests, err := srv.GetCoinRequestsByUserID(payer, entities.CoinRequestPending, 10)
+	if err != nil || len(requests) != 0 {
+		t.Fatalf("expected no pending requests, got (%v, %v)", requests, err)
+	}
+}
+
+func TestScheduledTransfers(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"manager", "teammate"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	manager, teammate := ids[0], ids[1]
+	due := time.Now().UTC().Add(-time.Minute).Truncate(time.Microsecond)
+
+	bonus := &entities.ScheduledTransfer{FromUserID: manager, ToUserID: teammate, Amount: 100, Message: "bonus", RunAt: &due, NextRunAt: &due}
+	if err := srv.CreateScheduledTransfer(bonus); err != nil {
+		t.Fatalf("expected CreateScheduledTransfer() to return nil, got %v", err)
+	}
+	if transfers, err := srv.GetDueScheduledTransfers(time.Now(), 100); err != nil || !slices.ContainsFunc(transfers, func(st entities.ScheduledTransfer) bool { return st.ID == bonus.ID }) {
+		t.Fatalf("expected GetDueScheduledTransfers() to return the transfer, got (%v, %v)", transfers, err)
+	}
+	run, err := srv.ExecuteScheduledTransfer(bonus.ID, due, nil, 3)
+	if err != nil || run == nil || !run.Success {
+		t.Fatalf("expected ExecuteScheduledTransfer() to succeed, got (%v, %v)", run, err)
+	}
+	if run, err := srv.ExecuteScheduledTransfer(bonus.ID, due, nil, 3); err != nil || run != nil {
+		t.Fatalf("expected a finished transfer not to run twice, got (%v, %v)", run, err)
+	}
+	if coins, err := srv.GetCoinsByUserID(teammate); err != nil || coins != testConfig.Wallet.InitialCoins+100 {
+		t.Fatalf("expected GetCoinsByUserID() to return %v, got %v", testConfig.Wallet.InitialCoins+100, coins)
+	}
+
+	next := due.Add(24 * time.Hour)
+	tooBig := &entities.ScheduledTransfer{FromUserID: manager, ToUserID: teammate, Amount: 1_000_000, Cron: "@daily", NextRunAt: &due}
+	if err := srv.CreateScheduledTransfer(tooBig); err != nil {
+		t.Fatalf("expected CreateScheduledTransfer() to return nil, got %v", err)
+	}
+	run, err = srv.ExecuteScheduledTransfer(tooBig.ID, due, &next, 1)
+	if err != nil || run == nil || run.Success {
+		t.Fatalf("expected ExecuteScheduledTransfer() to record a failed run, got (%v, %v)", run, err)
+	}
+	if transfer, err := srv.GetScheduledTransfer(tooBig.ID); err != nil || transfer.Active || transfer.ConsecutiveFailures != 1 {
+		t.Fatalf("expected the failing transfer to be deactivated, got (%v, %v)", transfer, err)
+	}
+	if runs, err := srv.GetScheduledTransferRuns(tooBig.ID, 10); err != nil || len(runs) != 1 || runs[0].Error == "" {
+		t.Fatalf("expected GetScheduledTransferRuns() to return the failed run, got (%v, %v)", runs, err)
+	}
+
+	// The recipient is deactivated between the scheduling and the run.
+	allowance := &entities.ScheduledTransfer{FromUserID: manager, ToUserID: teammate, Amount: 10, Cron: "@daily", NextRunAt: &due}
+	if err := srv.CreateScheduledTransfer(allowance); err != nil {
+		t.Fatalf("expected CreateScheduledTransfer() to return nil, got %v", err)
+	}
+	if _, err := testDB.Exec("UPDATE users SET deactivated_at = $1 WHERE id = $2", time.Now(), teammate); err != nil {
+		t.Fatalf("deactivate recipient: %v", err)
+	}
+	managerCoins, err := srv.GetCoinsByUserID(manager)
+	if err != nil {
+		t.Fatalf("Unexpected error while getting coins: %v", err)
+	}
+	run, err = srv.ExecuteScheduledTransfer(allowance.ID, due, &next, 3)
+	if err != nil || run == nil || run.Success || run.Error != customErrors.ErrUserDeactivated.Error() {
+		t.Fatalf("expected ExecuteScheduledTransfer() to record a run failed with ErrUserDeactivated, got (%v, %v)", run, err)
+	}
+	if transfer, err := srv.GetScheduledTransfer(allowance.ID); err != nil || !transfer.Active || transfer.ConsecutiveFailures != 1 || !transfer.NextRunAt.Equal(next) {
+		t.Fatalf("expected the transfer to move to its next run, got (%v, %v)", transfer, err)
+	}
+	if coins, err := srv.GetCoinsByUserID(manager); err != nil || coins != managerCoins {
+		t.Fatalf("expected the failed run to leave %v coins, got (%v, %v)", managerCoins, coins, err)
+	}
+}
+
+func TestScheduledTransferRunAtOffset(t *testing.T) {
+	srv := newTestService(t)
+	var ids []int
+	for _, username := range []string{"moscow_sender", "moscow_recipient"} {
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		ids = append(ids, user.ID)
+	}
+	// An hour from now, as a client three hours ahead of UTC would send it.
+	runAt := time.Now().UTC().Add(time.Hour).Truncate(time.Microsecond).In(time.FixedZone("MSK", 3*60*60))
+	transfer := &entities.ScheduledTransfer{FromUserID: ids[0], ToUserID: ids[1], Amount: 10, RunAt: &runAt, NextRunAt: &runAt}
+	if err := srv.CreateScheduledTransfer(transfer); err != nil {
+		t.Fatalf("expected CreateScheduledTransfer() to return nil, got %v", err)
+	}
+	stored, err := srv.GetScheduledTransfer(transfer.ID)
+	if err != nil {
+		t.Fatalf("expected GetScheduledTransfer() to return nil, got %v", err)
+	}
+	if !stored.RunAt.Equal(runAt) || !stored.NextRunAt.Equal(runAt) || !transfer.NextRunAt.Equal(*stored.NextRunAt) {
+		t.Fatalf("expected the stored run time to be %v, got run_at %v and next_run_at %v", runAt, stored.RunAt, stored.NextRunAt)
+	}
+	for _, now := range []time.Time{time.Now(), runAt.Add(-time.Minute)} {
+		transfers, err := srv.GetDueScheduledTransfers(now, 100)
+		if err != nil || slices.ContainsFunc(transfers, func(st entities.ScheduledTransfer) bool { return st.ID == transfer.ID }) {
+			t.Fatalf("expected the transfer not to be due at %v, got (%v, %v)", now, transfers, err)
+		}
+	}
+	if transfers, err := srv.GetDueScheduledTransfers(runAt, 100); err != nil || !slices.ContainsFunc(transfers, func(st entities.ScheduledTransfer) bool { return st.ID == transfer.ID }) {
+		t.Fatalf("expected the transfer to be due at %v, got (%v, %v)", runAt, transfers, err)
+	}
+}
+
+func TestTryAdvisoryLock(t *testing.T) {
+	srv := newTestService(t)
+	ctx := context.Background()
+	lock, err := srv.TryAdvisoryLock(ctx, 42)
+	if err != nil || lock == nil {
+		t.Fatalf("expected TryAdvisoryLock() to take the lock, got (%v, %v)", lock, err)
+	}
+	if other, err := srv.TryAdvisoryLock(ctx, 42); err != nil || other != nil {
+		t.Fatalf("expected TryAdvisoryLock() to fail while the lock is held, got (%v, %v)", other, err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("expected Release() to return nil, got %v", err)
+	}
+	lock, err = srv.TryAdvisoryLock(ctx, 42)
+	if err != nil || lock == nil {
+		t.Fatalf("expected TryAdvisoryLock() to take the released lock, got (%v, %v)", lock, err)
+	}
+	if err := lock.Release(); err != nil {
+		t.Fatalf("expected Release() to return nil, got %v", err)
+	}
 }
 
 func TestBuyItem(t *testing.T) {
-	srv := New()
-	err := srv.AddUser(&entities.User{Username: "knownuser", Password: "password"})
+	srv := newTestService(t)
+	err := srv.AddUser(context.Background(), &entities.User{Username: "knownuser", Password: "password"})
 	if err != nil {
 		t.Fatalf("Unexpected error while adding user: %v", err)
 	}
@@ -261,7 +1036,7 @@ func TestBuyItem(t *testing.T) {
 	if err != nil {
 		t.Fatalf("Unexpected error while getting user: %v", err)
 	}
-	if err := srv.BuyItem(user.ID, "hoody"); err != nil {
+	if _, err := srv.BuyItem(user.ID, "hoody", 1); err != nil {
 		t.Fatalf("expected BuyItem() to return nil, got %v", err)
 	}
 	inventory, err := srv.GetInventoryByUserID(user.ID)
@@ -273,10 +1048,278 @@ func TestBuyItem(t *testing.T) {
 	}
 }
 
+func TestOrderLifecycle(t *testing.T) {
+	srv := newTestService(t)
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "orderuser", Password: "password"}); err != nil {
+		t.Fatalf("Unexpected error while adding user: %v", err)
+	}
+	user, err := srv.GetUserByName("orderuser")
+	if err != nil {
+		t.Fatalf("Unexpected error while getting user: %v", err)
+	}
+	order, err := srv.BuyItem(user.ID, "cup", 1)
+	if err != nil {
+		t.Fatalf("expected BuyItem() to return nil, got %v", err)
+	}
+	if order.Status != entities.OrderPlaced || order.ItemType != "cup" {
+		t.Fatalf("expected BuyItem() to place a cup order, got %v", order)
+	}
+	if orders, err := srv.GetOrdersByUserID(user.ID, entities.OrderPlaced, 10); err != nil || len(orders) != 1 {
+		t.Fatalf("expected GetOrdersByUserID() to return 1 placed order, got (%v, %v)", orders, err)
+	}
+	if _, err := srv.UpdateOrderStatus(order.ID, entities.OrderDelivered); !errors.Is(err, customErrors.ErrInvalidTransition) {
+		t.Fatalf("expected UpdateOrderStatus() to return ErrInvalidTransition, got %v", err)
+	}
+	if order, err = srv.UpdateOrderStatus(order.ID, entities.OrderCancelled); err != nil || order.Status != entities.OrderCancelled {
+		t.Fatalf("expected UpdateOrderStatus() to cancel the order, got (%v, %v)", order, err)
+	}
+	if inventory, err := srv.GetInventoryByUserID(user.ID); err != nil || len(inventory) != 0 {
+		t.Fatalf("expected cancelled item to leave the inventory, got (%v, %v)", inventory, err)
+	}
+	if _, err := srv.UpdateOrderStatus(order.ID, entities.OrderReadyForPickup); !errors.Is(err, customErrors.ErrInvalidTransition) {
+		t.Fatalf("expected cancelled order to be final, got %v", err)
+	}
+}
+
+func TestRefundOrder(t *testing.T) {
+	srv := newTestService(t)
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "refunduser", Password: "password"}); err != nil {
+		t.Fatalf("Unexpected error while adding user: %v", err)
+	}
+	user, err := srv.GetUserByName("refunduser")
+	if err != nil {
+		t.Fatalf("Unexpected error while getting user: %v", err)
+	}
+	order, err := srv.BuyItem(user.ID, "hoody", 1)
+	if err != nil {
+		t.Fatalf("expected BuyItem() to return nil, got %v", err)
+	}
+	if _, err := srv.RefundOrder(order.ID, time.Now().Add(time.Hour)); !errors.Is(err, customErrors.ErrRefundWindowExpired) {
+		t.Fatalf("expected RefundOrder() to return ErrRefundWindowExpired, got %v", err)
+	}
+	if _, err := srv.RefundOrder(order.ID, time.Now().Add(-time.Hour)); err != nil {
+		t.Fatalf("expected RefundOrder() to return nil, got %v", err)
+	}
+	if coins, err := srv.GetCoinsByUserID(user.ID); err != nil || coins != testConfig.Wallet.InitialCoins {
+		t.Fatalf("expected GetCoinsByUserID() to return %v, got %v", testConfig.Wallet.InitialCoins, coins)
+	}
+	transactions, err := srv.GetTransactionsByUserID(user.ID)
+	if err != nil || len(transactions) != 1 {
+		t.Fatalf("expected GetTransactionsByUserID() to return 1 transaction, got (%v, %v)", transactions, err)
+	}
+	if refund := transactions[0]; refund.Type != entities.TransactionRefund || refund.OrderID != order.ID || refund.Amount != order.Price {
+		t.Fatalf("expected a refund of order %v, got %v", order.ID, refund)
+	}
+	if _, err := srv.RefundOrder(order.ID, time.Time{}); !errors.Is(err, customErrors.ErrInvalidTransition) {
+		t.Fatalf("expected second RefundOrder() to return ErrInvalidTransition, got %v", err)
+	}
+	order, err = srv.BuyItem(user.ID, "hoody", 1)
+	if err != nil {
+		t.Fatalf("expected BuyItem() to return nil, got %v", err)
+	}
+	if _, err := testDB.Exec("UPDATE coins SET amount = $1 WHERE user_id = $2", entities.MaxBalance-order.Price+1, user.ID); err != nil {
+		t.Fatalf("set coins: %v", err)
+	}
+	if _, err := srv.RefundOrder(order.ID, time.Time{}); !errors.Is(err, customErrors.ErrBalanceOverflow) {
+		t.Fatalf("expected RefundOrder() above the max balance to return ErrBalanceOverflow, got %v", err)
+	}
+}
+
+func TestCheckout(t *testing.T) {
+	srv := newTestService(t)
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "cartuser", Password: "password"}); err != nil {
+		t.Fatalf("Unexpected error while adding user: %v", err)
+	}
+	user, err := srv.GetUserByName("cartuser")
+	if err != nil {
+		t.Fatalf("Unexpected error while getting user: %v", err)
+	}
+	_, err = srv.Checkout(user.ID, []entities.CartLine{{ItemType: "pen", Quantity: 1}, {ItemType: "no-such-item", Quantity: 1}})
+	if !errors.Is(err, customErrors.ErrUnknownItem) {
+		t.Fatalf("expected Checkout() to return ErrUnknownItem, got %v", err)
+	}
+	if inventory, err := srv.GetInventoryByUserID(user.ID); err != nil || len(inventory) != 0 {
+		t.Fatalf("expected failed Checkout() to buy nothing, got (%v, %v)", inventory, err)
+	}
+
+	orders, err := srv.Checkout(user.ID, []entities.CartLine{
+		{ItemType: "cup", Quantity: 2},
+		{ItemType: "pen", Quantity: 3},
+		{ItemType: "cup", Quantity: 1},
+	})
+	if err != nil {
+		t.Fatalf("expected Checkout() to return nil, got %v", err)
+	}
+	if len(orders) != 2 || orders[0].ItemType != "cup" || orders[0].Quantity != 3 || orders[1].Quantity != 3 {
+		t.Fatalf("expected Checkout() to place 3 cups and 3 pens, got %v", orders)
+	}
+	if coins, err := srv.GetCoinsByUserID(user.ID); err != nil || coins != testConfig.Wallet.InitialCoins-90 {
+		t.Fatalf("expected GetCoinsByUserID() to return %v, got %v", testConfig.Wallet.InitialCoins-90, coins)
+	}
+
+	limit := 4
+	if _, err := srv.SetItemPurchaseLimit("cup", &limit); err != nil {
+		t.Fatalf("expected SetItemPurchaseLimit() to return nil, got %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := srv.SetItemPurchaseLimit("cup", nil); err != nil {
+			t.Errorf("Unexpected error while resetting purchase limit: %v", err)
+		}
+	})
+	if _, err := srv.BuyItem(user.ID, "cup", 2); !errors.Is(err, customErrors.ErrPurchaseLimit) {
+		t.Fatalf("expected BuyItem() to return ErrPurchaseLimit, got %v", err)
+	}
+	if _, err := srv.BuyItem(user.ID, "cup", 1); err != nil {
+		t.Fatalf("expected BuyItem() within the limit to return nil, got %v", err)
+	}
+}
+
+func TestBuyItemLimitedStock(t *testing.T) {
+	const (
+		itemType = "umbrella"
+		stock    = 5
+		buyers   = 20
+	)
+	srv := newTestService(t)
+	limit := stock
+	if _, err := srv.SetItemStock(itemType, &limit); err != nil {
+		t.Fatalf("expected SetItemStock() to return nil, got %v", err)
+	}
+	t.Cleanup(func() {
+		if _, err := srv.SetItemStock(itemType, nil); err != nil {
+			t.Errorf("Unexpected error while resetting stock: %v", err)
+		}
+	})
+	userIDs := make([]int, buyers)
+	for i := range userIDs {
+		username := fmt.Sprintf("stockuser%d", i)
+		if err := srv.AddUser(context.Background(), &entities.User{Username: username, Password: "password"}); err != nil {
+			t.Fatalf("Unexpected error while adding user: %v", err)
+		}
+		user, err := srv.GetUserByName(username)
+		if err != nil {
+			t.Fatalf("Unexpected error while getting user: %v", err)
+		}
+		userIDs[i] = user.ID
+	}
+
+	var wg sync.WaitGroup
+	results := make([]error, buyers)
+	orders := make([]*entities.Order, buyers)
+	for i, userId := range userIDs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			orders[i], results[i] = srv.BuyItem(userId, itemType, 1)
+		}()
+	}
+	wg.Wait()
+
+	var sold []*entities.Order
+	for i, err := range results {
+		switch {
+		case err == nil:
+			sold = append(sold, orders[i])
+		case !errors.Is(err, customErrors.ErrOutOfStock):
+			t.Fatalf("expected BuyItem() to return nil or ErrOutOfStock, got %v", err)
+		}
+	}
+	if len(sold) != stock {
+		t.Fatalf("expected %v purchases to succeed, got %v", stock, len(sold))
+	}
+	if left := itemStock(t, srv, itemType); left != 0 {
+		t.Fatalf("expected stock to be sold out, got %v", left)
+	}
+
+	if _, err := srv.RefundOrder(sold[0].ID, time.Time{}); err != nil {
+		t.Fatalf("expected RefundOrder() to return nil, got %v", err)
+	}
+	if left := itemStock(t, srv, itemType); left != 1 {
+		t.Fatalf("expected refund to return the item to stock, got %v", left)
+	}
+	if item, err := srv.RestockItem(itemType, 10); err != nil || item.Stock == nil || *item.Stock != 11 {
+		t.Fatalf("expected RestockItem() to raise stock to 11, got (%v, %v)", item, err)
+	}
+	if _, err := srv.SetItemStock(itemType, nil); err != nil {
+		t.Fatalf("expected SetItemStock() to return nil, got %v", err)
+	}
+	if item, err := srv.RestockItem(itemType, 10); !errors.Is(err, customErrors.ErrInvalidData) {
+		t.Fatalf("expected RestockItem() of an unlimited item to return ErrInvalidData, got (%v, %v)", item, err)
+	}
+}
+
+func itemStock(t *testing.T, srv Service, itemType string) int {
+	t.Helper()
+	items, err := srv.GetShopItems()
+	if err != nil {
+		t.Fatalf("expected GetShopItems() not return error, got %v", err)
+	}
+	for _, item := range items {
+		if item.ItemType == itemType && item.Stock != nil {
+			return *item.Stock
+		}
+	}
+	t.Fatalf("expected %v to have limited stock, got %v", itemType, items)
+	return 0
+}
+
 func TestClose(t *testing.T) {
-	srv := New()
+	requireDB(t)
+	db, err := Open(context.Background(), testConfig.Database)
+	if err != nil {
+		t.Fatalf("Unexpected error while opening database: %v", err)
+	}
+	srv := New(db, imcache.NewInMemoryCache(time.Minute), testConfig, clock.NewRealClock(), slog.Default())
 
 	if srv.Close() != nil {
 		t.Fatalf("expected Close() to return nil")
 	}
 }
+
+func TestOutboxEvents(t *testing.T) {
+	srv := newTestService(t)
+	if err := srv.AddUser(context.Background(), &entities.User{Username: "outboxuser", Password: "password"}); err != nil {
+		t.Fatalf("Unexpected error while adding user: %v", err)
+	}
+	claimed, err := srv.ClaimEvents(1000, time.Now().Add(time.Minute))
+	if err != nil {
+		t.Fatalf("expected ClaimEvents() not return error, got %v", err)
+	}
+	var registered *events.Event
+	for i := range claimed {
+		if claimed[i].Type == events.UserRegistered && strings.Contains(string(claimed[i].Payload), "outboxuser") {
+			registered = &claimed[i]
+		}
+	}
+	if registered == nil {
+		t.Fatalf("expected ClaimEvents() to return %v event for outboxuser, got %v", events.UserRegistered, claimed)
+	}
+	if again, err := srv.ClaimEvents(1000, time.Now().Add(time.Minute)); err != nil || len(again) != 0 {
+		t.Fatalf("expected leased events not to be claimed again, got (%v, %v)", again, err)
+	}
+	for range 2 {
+		if err := srv.MarkSinkDelivered(registered.ID, "file"); err != nil {
+			t.Fatalf("expected MarkSinkDelivered() to return nil, got %v", err)
+		}
+	}
+	if err := srv.MarkEventFailed(registered.ID, time.Now().Add(-time.Second), "http: unavailable"); err != nil {
+		t.Fatalf("expected MarkEventFailed() to return nil, got %v", err)
+	}
+	retried, err := srv.ClaimEvents(1000, time.Now().Add(time.Minute))
+	if err != nil || !slices.ContainsFunc(retried, func(event events.Event) bool {
+		return event.ID == registered.ID && slices.Equal(event.DeliveredSinks, []string{"file"})
+	}) {
+		t.Fatalf("expected the retried event to keep its delivered sinks once, got (%v, %v)", retried, err)
+	}
+	if err := srv.MarkEventDead(registered.ID, "http: unavailable"); err != nil {
+		t.Fatalf("expected MarkEventDead() to return nil, got %v", err)
+	}
+	if _, err := testDB.Exec("UPDATE outbox_events SET next_attempt_at = $1 WHERE id = $2", time.Now().Add(-time.Second), registered.ID); err != nil {
+		t.Fatalf("reset lease: %v", err)
+	}
+	if again, err := srv.ClaimEvents(1000, time.Now().Add(time.Minute)); err != nil || slices.ContainsFunc(again, func(event events.Event) bool {
+		return event.ID == registered.ID
+	}) {
+		t.Fatalf("expected a dead-lettered event not to be claimed, got (%v, %v)", again, err)
+	}
+}
@@ -0,0 +1,86 @@
+package database
+
+import (
+	"avitotech/migrations"
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+// ErrSchemaOutdated is returned when the database schema is behind the embedded migrations.
+var ErrSchemaOutdated = errors.New("database schema is outdated")
+
+func newMigrationProvider(db *sql.DB) (*goose.Provider, error) {
+	// The advisory lock serializes migrations of instances started with MIGRATE_ON_START at the same time.
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, err
+	}
+	return goose.NewProvider(goose.DialectPostgres, db, migrations.FS, goose.WithSessionLocker(locker))
+}
+
+// MigrateUp applies all pending migrations.
+func MigrateUp(ctx context.Context, db *sql.DB) error {
+	provider, err := newMigrationProvider(db)
+	if err != nil {
+		return err
+	}
+	results, err := provider.Up(ctx)
+	if err != nil {
+		return err
+	}
+	for _, result := range results {
+		slog.Info("Migration applied", "version", result.Source.Version, "source", result.Source.Path, "duration", result.Duration)
+	}
+	if len(results) == 0 {
+		slog.Info("No pending migrations")
+	}
+	return nil
+}
+
+// MigrateDown rolls back the most recently applied migration.
+func MigrateDown(ctx context.Context, db *sql.DB) error {
+	provider, err := newMigrationProvider(db)
+	if err != nil {
+		return err
+	}
+	result, err := provider.Down(ctx)
+	if err != nil {
+		return err
+	}
+	slog.Info("Migration rolled back", "version", result.Source.Version, "source", result.Source.Path, "duration", result.Duration)
+	return nil
+}
+
+// MigrationStatus returns the state of every embedded migration.
+func MigrationStatus(ctx context.Context, db *sql.DB) ([]*goose.MigrationStatus, error) {
+	provider, err := newMigrationProvider(db)
+	if err != nil {
+		return nil, err
+	}
+	return provider.Status(ctx)
+}
+
+// CheckSchemaVersion returns ErrSchemaOutdated if any embedded migration is not applied,
+// including a migration older than the latest applied one, e.g. merged out of order.
+func CheckSchemaVersion(ctx context.Context, db *sql.DB) error {
+	statuses, err := MigrationStatus(ctx, db)
+	if err != nil {
+		return err
+	}
+	var pending []int64
+	for _, status := range statuses {
+		if status.State == goose.StatePending {
+			pending = append(pending, status.Source.Version)
+		}
+	}
+	if len(pending) > 0 {
+		return fmt.Errorf("%w: pending migrations %v", ErrSchemaOutdated, pending)
+	}
+	return nil
+}
@@ -0,0 +1,218 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// OrderStore manages merch orders.
+type OrderStore interface {
+	// GetOrdersByUserID retrieves the latest orders of the user, optionally filtered by status.
+	GetOrdersByUserID(userId int, status entities.OrderStatus, limit int) ([]entities.Order, error)
+	// GetOrders retrieves the latest orders of all users, optionally filtered by status.
+	GetOrders(status entities.OrderStatus, limit int) ([]entities.Order, error)
+	// GetOrder retrieves the order by the given ID.
+	GetOrder(id int) (*entities.Order, error)
+	// UpdateOrderStatus moves the order to the given status if the transition is allowed.
+	// Cancelling an order refunds it.
+	UpdateOrderStatus(id int, status entities.OrderStatus) (*entities.Order, error)
+	// RefundOrder cancels the order, returns its price to the buyer, removes its items from the inventory
+	// and puts them back on sale.
+	// Orders placed before placedAfter are rejected with ErrRefundWindowExpired, a zero time disables the check.
+	RefundOrder(id int, placedAfter time.Time) (*entities.Order, error)
+}
+
+const orderColumns = "id, COALESCE(user_id, 0), item_type, quantity, price, status, created_at, updated_at"
+
+func scanOrder(row rowScanner) (*entities.Order, error) {
+	var order entities.Order
+	err := row.Scan(&order.ID, &order.UserID, &order.ItemType, &order.Quantity, &order.Price, &order.Status, &order.CreatedAt, &order.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+func (s *service) queryOrders(q querier, query string, args ...any) ([]entities.Order, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var orders []entities.Order
+	for rows.Next() {
+		order, err := scanOrder(rows)
+		if err != nil {
+			return nil, err
+		}
+		orders = append(orders, *order)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return orders, nil
+}
+
+// placeOrder inserts a new order in the placed status.
+func (s *service) placeOrder(q querier, userId int, itemType string, quantity, price int) (*entities.Order, error) {
+	now := s.clock.Now()
+	order := &entities.Order{
+		UserID:    userId,
+		ItemType:  itemType,
+		Quantity:  quantity,
+		Price:     price,
+		Status:    entities.OrderPlaced,
+		CreatedAt: now,
+		UpdatedAt: now,
+	}
+	err := q.QueryRow("INSERT INTO orders (user_id, item_type, quantity, price, status, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $6) RETURNING id",
+		userId, itemType, quantity, price, order.Status, now).Scan(&order.ID)
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// GetOrdersByUserID retrieves the latest orders of the user, optionally filtered by status.
+func (s *service) GetOrdersByUserID(userId int, status entities.OrderStatus, limit int) ([]entities.Order, error) {
+	return s.queryOrders(s.reader(userId), "SELECT "+orderColumns+" FROM orders WHERE user_id = $1 AND ($2 = '' OR status = $2) ORDER BY id DESC LIMIT $3", userId, status, limit)
+}
+
+// GetOrders retrieves the latest orders of all users, optionally filtered by status.
+func (s *service) GetOrders(status entities.OrderStatus, limit int) ([]entities.Order, error) {
+	return s.queryOrders(s.db, "SELECT "+orderColumns+" FROM orders WHERE $1 = '' OR status = $1 ORDER BY id DESC LIMIT $2", status, limit)
+}
+
+// GetOrder retrieves the order by the given ID.
+func (s *service) GetOrder(id int) (*entities.Order, error) {
+	order, err := scanOrder(s.db.QueryRow("SELECT "+orderColumns+" FROM orders WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return order, err
+}
+
+// lockOrder retrieves the order, locking it until the transaction ends.
+func (s *service) lockOrder(tx *sql.Tx, id int) (*entities.Order, error) {
+	order, err := scanOrder(tx.QueryRow("SELECT "+orderColumns+" FROM orders WHERE id = $1 FOR UPDATE", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return order, err
+}
+
+// UpdateOrderStatus moves the order to the given status if the transition is allowed.
+// Cancelling an order refunds it.
+func (s *service) UpdateOrderStatus(id int, status entities.OrderStatus) (*entities.Order, error) {
+	if status == entities.OrderCancelled {
+		return s.RefundOrder(id, time.Time{})
+	}
+	var order *entities.Order
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		order, err = s.lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		return s.transitionOrder(tx, order, status)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return order, nil
+}
+
+// RefundOrder cancels the order, returns its price to the buyer, removes its items from the inventory
+// and puts them back on sale.
+// Orders placed before placedAfter are rejected with ErrRefundWindowExpired, a zero time disables the check.
+// A refund that would push the buyer above the configured wallet max balance is rejected with
+// ErrMaxBalance, one above the storage limit with ErrBalanceOverflow.
+func (s *service) RefundOrder(id int, placedAfter time.Time) (*entities.Order, error) {
+	var order *entities.Order
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		order, err = s.lockOrder(tx, id)
+		if err != nil {
+			return err
+		}
+		if !order.Status.CanTransitionTo(entities.OrderCancelled) {
+			return customErrors.ErrInvalidTransition
+		}
+		if order.CreatedAt.Before(placedAfter) {
+			return customErrors.ErrRefundWindowExpired
+		}
+		if err := s.transitionOrder(tx, order, entities.OrderCancelled); err != nil {
+			return err
+		}
+		// The buyer may have been deleted, then there is nobody to refund.
+		if order.UserID == 0 {
+			return s.returnStock(tx, order.ItemType, order.Quantity)
+		}
+		coins, err := s.lockCoins(tx, order.UserID)
+		if err != nil {
+			return err
+		}
+		amount := order.Price * order.Quantity
+		if !entities.CanCredit(coins, amount) {
+			return customErrors.ErrBalanceOverflow
+		}
+		if err := s.checkMaxBalance(tx, order.UserID, coins, amount); err != nil {
+			return err
+		}
+		if err := s.updateCoins(tx, order.UserID, coins+amount); err != nil {
+			return err
+		}
+		// Rows are locked in the same order as BuyItem does: wallet, stock, inventory.
+		if err := s.returnStock(tx, order.ItemType, order.Quantity); err != nil {
+			return err
+		}
+		if err := s.removeItemFromInventory(tx, order.UserID, order.ItemType, order.Quantity); err != nil {
+			return err
+		}
+		return s.saveTransaction(tx, &entities.Transaction{
+			ToUserID: order.UserID,
+			Amount:   amount,
+			Type:     entities.TransactionRefund,
+			OrderID:  order.ID,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Delete(inventoryCacheKey(order.UserID))
+	s.pins.pin(s.clock.Now(), order.UserID)
+	return order, nil
+}
+
+// transitionOrder moves the locked order to the given status within the transaction.
+func (s *service) transitionOrder(tx *sql.Tx, order *entities.Order, status entities.OrderStatus) error {
+	if !order.Status.CanTransitionTo(status) {
+		return customErrors.ErrInvalidTransition
+	}
+	from := order.Status
+	order.Status = status
+	order.UpdatedAt = s.clock.Now()
+	if _, err := tx.Exec("UPDATE orders SET status = $1, updated_at = $2 WHERE id = $3", order.Status, order.UpdatedAt, order.ID); err != nil {
+		return err
+	}
+	return s.saveEvent(tx, events.OrderStatusChanged, events.OrderStatusChangedPayload{
+		OrderID:    order.ID,
+		UserID:     order.UserID,
+		ItemType:   order.ItemType,
+		FromStatus: string(from),
+		ToStatus:   string(status),
+	})
+}
+
+// removeItemFromInventory removes the given quantity of an item from the inventory of the user.
+func (s *service) removeItemFromInventory(q querier, userId int, itemType string, quantity int) error {
+	if _, err := q.Exec("UPDATE inventory SET quantity = GREATEST(quantity - $3, 0) WHERE user_id = $1 AND item_type = $2", userId, itemType, quantity); err != nil {
+		return err
+	}
+	_, err := q.Exec("DELETE FROM inventory WHERE user_id = $1 AND item_type = $2 AND quantity = 0", userId, itemType)
+	return err
+}
@@ -0,0 +1,96 @@
+package database
+
+import (
+	"avitotech/internal/events"
+	"cmp"
+	"encoding/json"
+	"slices"
+	"time"
+)
+
+// OutboxStore persists domain events until they are delivered.
+type OutboxStore interface {
+	// ClaimEvents locks up to limit due events until leaseUntil and returns them.
+	ClaimEvents(limit int, leaseUntil time.Time) ([]events.Event, error)
+	// MarkEventDelivered marks the event as delivered.
+	MarkEventDelivered(id int64) error
+	// MarkSinkDelivered records that the sink accepted the event, so retries skip it.
+	MarkSinkDelivered(id int64, sink string) error
+	// MarkEventFailed schedules the next delivery attempt of the event.
+	MarkEventFailed(id int64, nextAttemptAt time.Time, reason string) error
+	// MarkEventDead stops retrying the event, it stays in the outbox for inspection.
+	MarkEventDead(id int64, reason string) error
+}
+
+// saveEvent writes a domain event to the outbox as part of the given transaction.
+func (s *service) saveEvent(q querier, eventType events.Type, payload any) error {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	_, err = q.Exec("INSERT INTO outbox_events (event_type, payload, created_at, next_attempt_at) VALUES ($1, $2, $3, $3)", eventType, data, now)
+	return err
+}
+
+// ClaimEvents locks up to limit due events until leaseUntil and returns them.
+// Events claimed by another dispatcher are skipped.
+func (s *service) ClaimEvents(limit int, leaseUntil time.Time) ([]events.Event, error) {
+	rows, err := s.db.Query(`UPDATE outbox_events SET next_attempt_at = $2, attempts = attempts + 1
+		WHERE id IN (
+			SELECT id FROM outbox_events
+			WHERE delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= $1
+			ORDER BY id
+			LIMIT $3
+			FOR UPDATE SKIP LOCKED
+		)
+		RETURNING id, event_type, payload, created_at, attempts, delivered_sinks`, s.clock.Now(), leaseUntil, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var claimed []events.Event
+	for rows.Next() {
+		var event events.Event
+		var deliveredSinks []byte
+		if err := rows.Scan(&event.ID, &event.Type, &event.Payload, &event.OccurredAt, &event.Attempts, &deliveredSinks); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(deliveredSinks, &event.DeliveredSinks); err != nil {
+			return nil, err
+		}
+		claimed = append(claimed, event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	slices.SortFunc(claimed, func(a, b events.Event) int {
+		return cmp.Compare(a.ID, b.ID)
+	})
+	return claimed, nil
+}
+
+// MarkEventDelivered marks the event as delivered.
+func (s *service) MarkEventDelivered(id int64) error {
+	_, err := s.db.Exec("UPDATE outbox_events SET delivered_at = $1, last_error = NULL WHERE id = $2", s.clock.Now(), id)
+	return err
+}
+
+// MarkSinkDelivered records that the sink accepted the event, so retries skip it.
+func (s *service) MarkSinkDelivered(id int64, sink string) error {
+	_, err := s.db.Exec(`UPDATE outbox_events SET delivered_sinks = delivered_sinks || jsonb_build_array($1::text)
+		WHERE id = $2 AND NOT delivered_sinks @> jsonb_build_array($1::text)`, sink, id)
+	return err
+}
+
+// MarkEventFailed schedules the next delivery attempt of the event.
+func (s *service) MarkEventFailed(id int64, nextAttemptAt time.Time, reason string) error {
+	_, err := s.db.Exec("UPDATE outbox_events SET next_attempt_at = $1, last_error = $2 WHERE id = $3", nextAttemptAt, reason, id)
+	return err
+}
+
+// MarkEventDead stops retrying the event, it stays in the outbox for inspection.
+func (s *service) MarkEventDead(id int64, reason string) error {
+	_, err := s.db.Exec("UPDATE outbox_events SET dead_at = $1, last_error = $2 WHERE id = $3", s.clock.Now(), reason, id)
+	return err
+}
@@ -0,0 +1,143 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// BuyItem buys units of an item for the given user and places an order for them.
+func (s *service) BuyItem(userId int, itemType string, quantity int) (*entities.Order, error) {
+	orders, err := s.Checkout(userId, []entities.CartLine{{ItemType: itemType, Quantity: quantity}})
+	if err != nil {
+		return nil, err
+	}
+	return &orders[0], nil
+}
+
+// Checkout buys every line of the cart in one transaction, placing an order per item.
+// Lines of the same item are merged. Nothing is bought if any line fails.
+func (s *service) Checkout(userId int, lines []entities.CartLine) ([]entities.Order, error) {
+	lines, err := mergeCartLines(lines)
+	if err != nil {
+		return nil, err
+	}
+	var orders []entities.Order
+	err = s.inTx(func(tx *sql.Tx) error {
+		// Locking the wallet first serializes purchases of the same user,
+		// so the purchase limits below cannot be raced past.
+		coins, err := s.lockCoins(tx, userId)
+		if err != nil {
+			return err
+		}
+		// A token issued before the deactivation may still reach here.
+		if err := checkActive(tx, userId, userId); err != nil {
+			return err
+		}
+
+		items := make([]*entities.ShopItem, len(lines))
+		total := 0
+		for i, line := range lines {
+			item, err := s.getShopItem(tx, line.ItemType)
+			if errors.Is(err, customErrors.ErrNotFound) {
+				return fmt.Errorf("%w: %s", customErrors.ErrUnknownItem, line.ItemType)
+			}
+			if err != nil {
+				return err
+			}
+			if item.MaxPerUser != nil {
+				bought, err := s.purchasedQuantity(tx, userId, line.ItemType)
+				if err != nil {
+					return err
+				}
+				if bought+line.Quantity > *item.MaxPerUser {
+					return fmt.Errorf("%w: %s allows %d per user", customErrors.ErrPurchaseLimit, line.ItemType, *item.MaxPerUser)
+				}
+			}
+			items[i] = item
+			total += item.Price * line.Quantity
+		}
+
+		if coins < total {
+			return customErrors.ErrNotEnoughCoins
+		}
+		if err := s.checkPurchasePolicy(tx, userId, total); err != nil {
+			return err
+		}
+
+		// Stock rows are locked in item order, so concurrent carts cannot deadlock.
+		for i, line := range lines {
+			if items[i].Stock == nil {
+				continue
+			}
+			if err := s.takeStock(tx, line.ItemType, line.Quantity); err != nil {
+				return fmt.Errorf("%w: %s", err, line.ItemType)
+			}
+		}
+
+		if err := s.updateCoins(tx, userId, coins-total); err != nil {
+			return err
+		}
+
+		for i, line := range lines {
+			if err := s.addItemToInventory(tx, userId, line.ItemType, line.Quantity); err != nil {
+				return err
+			}
+			order, err := s.placeOrder(tx, userId, line.ItemType, line.Quantity, items[i].Price)
+			if err != nil {
+				return err
+			}
+			if err := s.saveEvent(tx, events.ItemPurchased, events.ItemPurchasedPayload{
+				OrderID:  order.ID,
+				UserID:   userId,
+				ItemType: line.ItemType,
+				Quantity: line.Quantity,
+				Price:    items[i].Price,
+			}); err != nil {
+				return err
+			}
+			orders = append(orders, *order)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Delete(inventoryCacheKey(userId))
+	s.pins.pin(s.clock.Now(), userId)
+	return orders, nil
+}
+
+// purchasedQuantity returns how many units of the item the user holds in orders that were not cancelled.
+func (s *service) purchasedQuantity(q querier, userId int, itemType string) (int, error) {
+	var quantity int
+	err := q.QueryRow("SELECT COALESCE(SUM(quantity), 0) FROM orders WHERE user_id = $1 AND item_type = $2 AND status <> $3",
+		userId, itemType, entities.OrderCancelled).Scan(&quantity)
+	return quantity, err
+}
+
+// mergeCartLines sums the quantities of repeated items and sorts the lines by item type.
+func mergeCartLines(lines []entities.CartLine) ([]entities.CartLine, error) {
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("%w: empty cart", customErrors.ErrInvalidData)
+	}
+	merged := make([]entities.CartLine, 0, len(lines))
+	for _, line := range lines {
+		if line.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity of %s must be positive", customErrors.ErrInvalidData, line.ItemType)
+		}
+		i := slices.IndexFunc(merged, func(l entities.CartLine) bool { return l.ItemType == line.ItemType })
+		if i < 0 {
+			merged = append(merged, line)
+			continue
+		}
+		merged[i].Quantity += line.Quantity
+	}
+	slices.SortFunc(merged, func(a, b entities.CartLine) int { return strings.Compare(a.ItemType, b.ItemType) })
+	return merged, nil
+}
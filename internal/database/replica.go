@@ -0,0 +1,70 @@
+package database
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+)
+
+// WithReplica routes read-only queries to the given replica pool.
+// A non-zero readYourWrites window pins a user to the primary for that long after they write,
+// so they never read data older than their own writes.
+func WithReplica(replica *sql.DB, readYourWrites time.Duration) Option {
+	return func(s *service) {
+		s.replica = replica
+		if readYourWrites > 0 {
+			s.pins = &primaryPins{window: readYourWrites, until: make(map[int]time.Time)}
+		}
+	}
+}
+
+// reader returns the pool serving read-only queries of the given user.
+func (s *service) reader(userId int) querier {
+	if s.replica == nil || s.pins.pinned(s.clock.Now(), userId) {
+		return s.db
+	}
+	return s.replica
+}
+
+// catalogReader returns the pool serving shop catalog reads, leaderboards and the user directory,
+// which are shared by all users and tolerate replication lag.
+func (s *service) catalogReader() querier {
+	if s.replica == nil {
+		return s.db
+	}
+	return s.replica
+}
+
+// primaryPins tracks users that must read from the primary after a recent write.
+// A nil *primaryPins pins nobody.
+type primaryPins struct {
+	mu     sync.Mutex
+	window time.Duration
+	until  map[int]time.Time
+}
+
+func (p *primaryPins) pin(now time.Time, userIds ...int) {
+	if p == nil {
+		return
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	for id, until := range p.until {
+		if now.After(until) {
+			delete(p.until, id)
+		}
+	}
+	for _, id := range userIds {
+		p.until[id] = now.Add(p.window)
+	}
+}
+
+func (p *primaryPins) pinned(now time.Time, userId int) bool {
+	if p == nil {
+		return false
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.until[userId]
+	return ok && !now.After(until)
+}
@@ -0,0 +1,110 @@
+package database
+
+import (
+	"avitotech/internal/config"
+	"avitotech/pkg/imcache"
+	"database/sql"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+// newReplicaTestService builds a service whose pools are never connected, it only checks routing.
+func newReplicaTestService(t *testing.T, window time.Duration) (*service, *manualClock) {
+	t.Helper()
+	primary, err := sql.Open("pgx", "postgres://primary.invalid/db")
+	if err != nil {
+		t.Fatalf("open primary: %v", err)
+	}
+	replica, err := sql.Open("pgx", "postgres://replica.invalid/db")
+	if err != nil {
+		t.Fatalf("open replica: %v", err)
+	}
+	t.Cleanup(func() {
+		primary.Close()
+		replica.Close()
+	})
+	clk := &manualClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	cache := imcache.NewInMemoryCache(time.Minute)
+	t.Cleanup(cache.Close)
+	srv := New(primary, cache, config.Default(), clk, slog.Default(), WithReplica(replica, window))
+	return srv.(*service), clk
+}
+
+func TestReaderRouting(t *testing.T) {
+	srv, clk := newReplicaTestService(t, 5*time.Second)
+
+	if srv.reader(1) != srv.replica {
+		t.Fatal("expected reads of a user without writes to go to the replica")
+	}
+	if srv.catalogReader() != srv.replica {
+		t.Fatal("expected catalog reads to go to the replica")
+	}
+
+	srv.pins.pin(clk.Now(), 1, 2)
+	for _, userId := range []int{1, 2} {
+		if srv.reader(userId) != srv.db {
+			t.Fatalf("expected user %d to read from the primary right after a write", userId)
+		}
+	}
+	if srv.reader(3) != srv.replica {
+		t.Fatal("expected a pin to affect only the users who wrote")
+	}
+	if srv.catalogReader() != srv.replica {
+		t.Fatal("expected catalog reads to ignore read-your-writes pins")
+	}
+
+	clk.now = clk.now.Add(5 * time.Second)
+	if srv.reader(1) != srv.db {
+		t.Fatal("expected the pin to hold until the end of the window")
+	}
+	clk.now = clk.now.Add(time.Millisecond)
+	if srv.reader(1) != srv.replica {
+		t.Fatal("expected the pin to expire after the window")
+	}
+}
+
+func TestReaderWithoutReplica(t *testing.T) {
+	primary, err := sql.Open("pgx", "postgres://primary.invalid/db")
+	if err != nil {
+		t.Fatalf("open primary: %v", err)
+	}
+	defer primary.Close()
+	cache := imcache.NewInMemoryCache(time.Minute)
+	defer cache.Close()
+	srv := New(primary, cache, config.Default(), &manualClock{}, slog.Default()).(*service)
+
+	if srv.reader(1) != srv.db || srv.catalogReader() != srv.db {
+		t.Fatal("expected every read to go to the primary without a replica")
+	}
+	srv.pins.pin(time.Now(), 1) // a nil pin set ignores writes
+}
+
+func TestReaderWithoutReadYourWrites(t *testing.T) {
+	srv, clk := newReplicaTestService(t, 0)
+	srv.pins.pin(clk.Now(), 1)
+	if srv.reader(1) != srv.replica {
+		t.Fatal("expected a zero window to leave writers on the replica")
+	}
+}
+
+func TestPrimaryPinsPruneExpired(t *testing.T) {
+	pins := &primaryPins{window: time.Second, until: make(map[int]time.Time)}
+	now := time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)
+	pins.pin(now, 1)
+	pins.pin(now.Add(2*time.Second), 2)
+	if _, ok := pins.until[1]; ok {
+		t.Fatal("expected an expired pin to be pruned on the next write")
+	}
+	if !pins.pinned(now.Add(2*time.Second), 2) {
+		t.Fatal("expected the new pin to be kept")
+	}
+}
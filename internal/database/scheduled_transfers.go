@@ -0,0 +1,258 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"context"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// ScheduledTransferStore manages scheduled coin transfers and their executions.
+type ScheduledTransferStore interface {
+	// CreateScheduledTransfer inserts a new active scheduled transfer.
+	CreateScheduledTransfer(transfer *entities.ScheduledTransfer) error
+	// GetScheduledTransfersByUserID retrieves the scheduled transfers created by the user.
+	GetScheduledTransfersByUserID(userId int) ([]entities.ScheduledTransfer, error)
+	// GetScheduledTransfer retrieves the scheduled transfer by the given ID.
+	GetScheduledTransfer(id int) (*entities.ScheduledTransfer, error)
+	// CancelScheduledTransfer deactivates a transfer created by the user.
+	CancelScheduledTransfer(id, userId int) error
+	// GetScheduledTransferRuns retrieves the latest executions of the transfer.
+	GetScheduledTransferRuns(transferId, limit int) ([]entities.ScheduledTransferRun, error)
+	// GetDueScheduledTransfers retrieves active transfers whose next run is not after now.
+	GetDueScheduledTransfers(now time.Time, limit int) ([]entities.ScheduledTransfer, error)
+	// ExecuteScheduledTransfer runs the transfer due at scheduledFor and records the run.
+	// The transfer then moves to nextRunAt, or finishes when it is nil. A run the sender
+	// cannot afford is recorded as failed, after maxFailures failures in a row the transfer
+	// is deactivated. It returns nil when the run was already executed or cancelled.
+	ExecuteScheduledTransfer(id int, scheduledFor time.Time, nextRunAt *time.Time, maxFailures int) (*entities.ScheduledTransferRun, error)
+	// TryAdvisoryLock takes the session advisory lock with the given key on a dedicated connection.
+	// It returns nil when another session holds the lock.
+	TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error)
+}
+
+const scheduledTransferColumns = "id, from_user_id, to_user_id, amount, message, run_at, cron, next_run_at, active, consecutive_failures, created_at, updated_at"
+
+func scanScheduledTransfer(row rowScanner) (*entities.ScheduledTransfer, error) {
+	var transfer entities.ScheduledTransfer
+	var runAt, nextRunAt sql.NullTime
+	err := row.Scan(&transfer.ID, &transfer.FromUserID, &transfer.ToUserID, &transfer.Amount, &transfer.Message, &runAt, &transfer.Cron,
+		&nextRunAt, &transfer.Active, &transfer.ConsecutiveFailures, &transfer.CreatedAt, &transfer.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if runAt.Valid {
+		transfer.RunAt = &runAt.Time
+	}
+	if nextRunAt.Valid {
+		transfer.NextRunAt = &nextRunAt.Time
+	}
+	return &transfer, nil
+}
+
+func (s *service) queryScheduledTransfers(query string, args ...any) ([]entities.ScheduledTransfer, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var transfers []entities.ScheduledTransfer
+	for rows.Next() {
+		transfer, err := scanScheduledTransfer(rows)
+		if err != nil {
+			return nil, err
+		}
+		transfers = append(transfers, *transfer)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return transfers, nil
+}
+
+// CreateScheduledTransfer inserts a new active scheduled transfer.
+func (s *service) CreateScheduledTransfer(transfer *entities.ScheduledTransfer) error {
+	now := s.clock.Now()
+	transfer.Active = true
+	transfer.CreatedAt = now
+	transfer.UpdatedAt = now
+	transfer.RunAt = utc(transfer.RunAt)
+	transfer.NextRunAt = utc(transfer.NextRunAt)
+	return s.db.QueryRow(`INSERT INTO scheduled_transfers (from_user_id, to_user_id, amount, message, run_at, cron, next_run_at, active, created_at, updated_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, TRUE, $8, $8) RETURNING id`,
+		transfer.FromUserID, transfer.ToUserID, transfer.Amount, transfer.Message, transfer.RunAt, transfer.Cron, transfer.NextRunAt, now).Scan(&transfer.ID)
+}
+
+// GetScheduledTransfersByUserID retrieves the scheduled transfers created by the user.
+func (s *service) GetScheduledTransfersByUserID(userId int) ([]entities.ScheduledTransfer, error) {
+	return s.queryScheduledTransfers("SELECT "+scheduledTransferColumns+" FROM scheduled_transfers WHERE from_user_id = $1 ORDER BY id DESC", userId)
+}
+
+// GetScheduledTransfer retrieves the scheduled transfer by the given ID.
+func (s *service) GetScheduledTransfer(id int) (*entities.ScheduledTransfer, error) {
+	transfer, err := scanScheduledTransfer(s.db.QueryRow("SELECT "+scheduledTransferColumns+" FROM scheduled_transfers WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return transfer, err
+}
+
+// CancelScheduledTransfer deactivates a transfer created by the user.
+func (s *service) CancelScheduledTransfer(id, userId int) error {
+	res, err := s.db.Exec("UPDATE scheduled_transfers SET active = FALSE, next_run_at = NULL, updated_at = $3 WHERE id = $1 AND from_user_id = $2",
+		id, userId, s.clock.Now())
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return customErrors.ErrNotFound
+	}
+	return nil
+}
+
+// GetScheduledTransferRuns retrieves the latest executions of the transfer.
+func (s *service) GetScheduledTransferRuns(transferId, limit int) ([]entities.ScheduledTransferRun, error) {
+	rows, err := s.db.Query("SELECT id, scheduled_transfer_id, scheduled_for, executed_at, success, error FROM scheduled_transfer_runs WHERE scheduled_transfer_id = $1 ORDER BY id DESC LIMIT $2",
+		transferId, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var runs []entities.ScheduledTransferRun
+	for rows.Next() {
+		var run entities.ScheduledTransferRun
+		if err := rows.Scan(&run.ID, &run.ScheduledTransferID, &run.ScheduledFor, &run.ExecutedAt, &run.Success, &run.Error); err != nil {
+			return nil, err
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return runs, nil
+}
+
+// GetDueScheduledTransfers retrieves active transfers whose next run is not after now.
+func (s *service) GetDueScheduledTransfers(now time.Time, limit int) ([]entities.ScheduledTransfer, error) {
+	return s.queryScheduledTransfers("SELECT "+scheduledTransferColumns+" FROM scheduled_transfers WHERE active AND next_run_at <= $1 ORDER BY next_run_at LIMIT $2", now.UTC(), limit)
+}
+
+// ExecuteScheduledTransfer runs the transfer due at scheduledFor and records the run.
+// The transfer then moves to nextRunAt, or finishes when it is nil. A run rejected with a
+// domain error, such as a sender who cannot afford it or a deactivated recipient, is recorded
+// as failed, after maxFailures failures in a row the transfer is deactivated. It returns nil
+// when the run was already executed or cancelled.
+func (s *service) ExecuteScheduledTransfer(id int, scheduledFor time.Time, nextRunAt *time.Time, maxFailures int) (*entities.ScheduledTransferRun, error) {
+	var run *entities.ScheduledTransferRun
+	var transfer *entities.ScheduledTransfer
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		transfer, err = scanScheduledTransfer(tx.QueryRow("SELECT "+scheduledTransferColumns+" FROM scheduled_transfers WHERE id = $1 FOR UPDATE", id))
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if !transfer.Active || transfer.NextRunAt == nil || !transfer.NextRunAt.Equal(scheduledFor) {
+			return nil
+		}
+
+		run = &entities.ScheduledTransferRun{
+			ScheduledTransferID: id,
+			ScheduledFor:        scheduledFor,
+			ExecutedAt:          s.clock.Now(),
+			Success:             true,
+		}
+		// The savepoint undoes a failed transfer, so its run can still be recorded in this transaction.
+		if _, err := tx.Exec("SAVEPOINT scheduled_transfer_run"); err != nil {
+			return err
+		}
+		err = s.transfer(tx, transfer.FromUserID, transfer.ToUserID, transfer.Amount, transfer.Message)
+		switch _, domain := customErrors.As(err); {
+		case err == nil:
+			transfer.ConsecutiveFailures = 0
+		case domain:
+			if _, err := tx.Exec("ROLLBACK TO SAVEPOINT scheduled_transfer_run"); err != nil {
+				return err
+			}
+			run.Success = false
+			run.Error = err.Error()
+			transfer.ConsecutiveFailures++
+		default:
+			return err
+		}
+
+		transfer.NextRunAt = utc(nextRunAt)
+		if transfer.ConsecutiveFailures >= maxFailures {
+			transfer.NextRunAt = nil
+		}
+		transfer.Active = transfer.NextRunAt != nil
+		transfer.UpdatedAt = run.ExecutedAt
+		_, err = tx.Exec("UPDATE scheduled_transfers SET next_run_at = $1, active = $2, consecutive_failures = $3, updated_at = $4 WHERE id = $5",
+			transfer.NextRunAt, transfer.Active, transfer.ConsecutiveFailures, transfer.UpdatedAt, id)
+		if err != nil {
+			return err
+		}
+		return tx.QueryRow("INSERT INTO scheduled_transfer_runs (scheduled_transfer_id, scheduled_for, executed_at, success, error) VALUES ($1, $2, $3, $4, $5) RETURNING id",
+			run.ScheduledTransferID, run.ScheduledFor, run.ExecutedAt, run.Success, run.Error).Scan(&run.ID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	if run != nil && run.Success {
+		s.pins.pin(s.clock.Now(), transfer.FromUserID, transfer.ToUserID)
+	}
+	return run, nil
+}
+
+// AdvisoryLock is a session-level Postgres advisory lock held on a dedicated connection.
+type AdvisoryLock struct {
+	conn *sql.Conn
+	key  int64
+}
+
+// TryAdvisoryLock takes the session advisory lock with the given key on a dedicated connection.
+// It returns nil when another session holds the lock.
+func (s *service) TryAdvisoryLock(ctx context.Context, key int64) (*AdvisoryLock, error) {
+	conn, err := s.db.Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var locked bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", key).Scan(&locked); err != nil {
+		return nil, errors.Join(err, conn.Close())
+	}
+	if !locked {
+		return nil, conn.Close()
+	}
+	return &AdvisoryLock{conn: conn, key: key}, nil
+}
+
+// Held reports an error when the connection holding the lock is lost, and the lock with it.
+func (l *AdvisoryLock) Held(ctx context.Context) error {
+	return l.conn.PingContext(ctx)
+}
+
+// Release unlocks the lock and returns its connection to the pool.
+func (l *AdvisoryLock) Release() error {
+	_, err := l.conn.ExecContext(context.Background(), "SELECT pg_advisory_unlock($1)", l.key)
+	return errors.Join(err, l.conn.Close())
+}
+
+// utc converts a run time to UTC before it is stored or compared. The columns are TIMESTAMP,
+// which keep the wall clock of a time and drop its offset, so a client's "10:00+03:00" would
+// otherwise run at 10:00 UTC.
+func utc(t *time.Time) *time.Time {
+	if t == nil {
+		return nil
+	}
+	u := t.UTC()
+	return &u
+}
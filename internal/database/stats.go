@@ -0,0 +1,94 @@
+package database
+
+import (
+	"avitotech/internal/entities"
+	"database/sql"
+	"errors"
+	"time"
+)
+
+// StatsStore computes leaderboards and wallet statistics with SQL aggregates.
+// Leaderboards leave out deactivated and erased users.
+type StatsStore interface {
+	// GetTopSenders retrieves the users who sent the most coins since the given time.
+	GetTopSenders(since time.Time, limit int) ([]entities.LeaderboardEntry, error)
+	// GetTopReceivers retrieves the users who received the most coins since the given time.
+	GetTopReceivers(since time.Time, limit int) ([]entities.LeaderboardEntry, error)
+	// GetTopSpenders retrieves the users who spent the most coins in the shop since the given time.
+	GetTopSpenders(since time.Time, limit int) ([]entities.LeaderboardEntry, error)
+	// GetWalletStats computes the all-time totals of the user's wallet.
+	GetWalletStats(userId int) (*entities.WalletStats, error)
+}
+
+// GetTopSenders retrieves the users who sent the most coins since the given time.
+func (s *service) GetTopSenders(since time.Time, limit int) ([]entities.LeaderboardEntry, error) {
+	return s.queryLeaderboard(`SELECT u.id, u.username, t.total, t.count FROM (
+			SELECT from_user_id AS user_id, SUM(amount) AS total, COUNT(*) AS count FROM coin_transactions
+			WHERE transaction_type = $1 AND created_at >= $2 AND from_user_id IS NOT NULL GROUP BY from_user_id
+		) t JOIN users u ON u.id = t.user_id
+		WHERE u.deactivated_at IS NULL AND u.erased_at IS NULL ORDER BY t.total DESC, u.id LIMIT $3`, entities.TransactionSend, since, limit)
+}
+
+// GetTopReceivers retrieves the users who received the most coins since the given time.
+func (s *service) GetTopReceivers(since time.Time, limit int) ([]entities.LeaderboardEntry, error) {
+	return s.queryLeaderboard(`SELECT u.id, u.username, t.total, t.count FROM (
+			SELECT to_user_id AS user_id, SUM(amount) AS total, COUNT(*) AS count FROM coin_transactions
+			WHERE transaction_type = $1 AND created_at >= $2 AND to_user_id IS NOT NULL GROUP BY to_user_id
+		) t JOIN users u ON u.id = t.user_id
+		WHERE u.deactivated_at IS NULL AND u.erased_at IS NULL ORDER BY t.total DESC, u.id LIMIT $3`, entities.TransactionSend, since, limit)
+}
+
+// GetTopSpenders retrieves the users who spent the most coins in the shop since the given time.
+// Cancelled orders were refunded and do not count.
+func (s *service) GetTopSpenders(since time.Time, limit int) ([]entities.LeaderboardEntry, error) {
+	return s.queryLeaderboard(`SELECT u.id, u.username, t.total, t.count FROM (
+			SELECT user_id, SUM(price * quantity) AS total, COUNT(*) AS count FROM orders
+			WHERE status <> $1 AND created_at >= $2 GROUP BY user_id
+		) t JOIN users u ON u.id = t.user_id
+		WHERE u.deactivated_at IS NULL AND u.erased_at IS NULL ORDER BY t.total DESC, u.id LIMIT $3`, entities.OrderCancelled, since, limit)
+}
+
+func (s *service) queryLeaderboard(query string, args ...any) ([]entities.LeaderboardEntry, error) {
+	rows, err := s.catalogReader().Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	entries := []entities.LeaderboardEntry{}
+	for rows.Next() {
+		var entry entities.LeaderboardEntry
+		if err := rows.Scan(&entry.UserID, &entry.Username, &entry.Total, &entry.Count); err != nil {
+			return nil, err
+		}
+		entries = append(entries, entry)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// GetWalletStats computes the all-time totals of the user's wallet.
+func (s *service) GetWalletStats(userId int) (*entities.WalletStats, error) {
+	q := s.reader(userId)
+	var stats entities.WalletStats
+	err := q.QueryRow(`SELECT COALESCE(SUM(amount) FILTER (WHERE from_user_id = $1), 0),
+			COALESCE(SUM(amount) FILTER (WHERE to_user_id = $1), 0),
+			COUNT(DISTINCT CASE WHEN from_user_id = $1 THEN to_user_id ELSE from_user_id END)
+		FROM coin_transactions WHERE transaction_type = $2 AND (from_user_id = $1 OR to_user_id = $1)`,
+		userId, entities.TransactionSend).Scan(&stats.Sent, &stats.Received, &stats.Counterparties)
+	if err != nil {
+		return nil, err
+	}
+	err = q.QueryRow("SELECT COALESCE(SUM(price * quantity), 0) FROM orders WHERE user_id = $1 AND status <> $2",
+		userId, entities.OrderCancelled).Scan(&stats.Spent)
+	if err != nil {
+		return nil, err
+	}
+	err = q.QueryRow("SELECT item_type FROM inventory WHERE user_id = $1 AND quantity > 0 ORDER BY quantity DESC, item_type LIMIT 1",
+		userId).Scan(&stats.FavouriteItem)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return nil, err
+	}
+	return &stats, nil
+}
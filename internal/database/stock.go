@@ -0,0 +1,104 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"database/sql"
+	"errors"
+	"fmt"
+)
+
+// StockStore manages the stock and purchase limits of shop items.
+type StockStore interface {
+	// RestockItem adds the given quantity to the stock of a limited item, unlimited items are rejected.
+	RestockItem(itemType string, quantity int) (*entities.ShopItem, error)
+	// SetItemStock sets the stock of the item, nil makes the item unlimited.
+	SetItemStock(itemType string, stock *int) (*entities.ShopItem, error)
+	// SetItemPurchaseLimit sets how many units of the item a user may buy, nil removes the limit.
+	SetItemPurchaseLimit(itemType string, maxPerUser *int) (*entities.ShopItem, error)
+}
+
+const shopItemColumns = "item_type, price, stock, max_per_user"
+
+func scanShopItem(row rowScanner) (*entities.ShopItem, error) {
+	var item entities.ShopItem
+	var stock, maxPerUser sql.NullInt32
+	if err := row.Scan(&item.ItemType, &item.Price, &stock, &maxPerUser); err != nil {
+		return nil, err
+	}
+	if stock.Valid {
+		left := int(stock.Int32)
+		item.Stock = &left
+	}
+	if maxPerUser.Valid {
+		limit := int(maxPerUser.Int32)
+		item.MaxPerUser = &limit
+	}
+	return &item, nil
+}
+
+// RestockItem adds the given quantity to the stock of a limited item. Restocking an unlimited
+// item would change nothing, so it fails with ErrInvalidData to tell the admin.
+func (s *service) RestockItem(itemType string, quantity int) (*entities.ShopItem, error) {
+	item, err := scanShopItem(s.db.QueryRow("UPDATE shop SET stock = stock + $2 WHERE item_type = $1 RETURNING "+shopItemColumns, itemType, quantity))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if item.Stock == nil {
+		return nil, fmt.Errorf("%w: item has unlimited stock", customErrors.ErrInvalidData)
+	}
+	return item, nil
+}
+
+// SetItemStock sets the stock of the item, nil makes the item unlimited.
+func (s *service) SetItemStock(itemType string, stock *int) (*entities.ShopItem, error) {
+	item, err := scanShopItem(s.db.QueryRow("UPDATE shop SET stock = $2 WHERE item_type = $1 RETURNING "+shopItemColumns, itemType, stock))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return item, err
+}
+
+// SetItemPurchaseLimit sets how many units of the item a user may buy, nil removes the limit.
+func (s *service) SetItemPurchaseLimit(itemType string, maxPerUser *int) (*entities.ShopItem, error) {
+	item, err := scanShopItem(s.db.QueryRow("UPDATE shop SET max_per_user = $2 WHERE item_type = $1 RETURNING "+shopItemColumns, itemType, maxPerUser))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return item, err
+}
+
+// getShopItem retrieves the item by the given item type.
+func (s *service) getShopItem(q querier, itemType string) (*entities.ShopItem, error) {
+	item, err := scanShopItem(q.QueryRow("SELECT "+shopItemColumns+" FROM shop WHERE item_type = $1", itemType))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return item, err
+}
+
+// takeStock reserves units of a limited item, failing with ErrOutOfStock when not enough are left.
+// The conditional update locks the row and rechecks the stock, so concurrent purchases cannot oversell.
+func (s *service) takeStock(q querier, itemType string, quantity int) error {
+	res, err := q.Exec("UPDATE shop SET stock = stock - $2 WHERE item_type = $1 AND stock >= $2", itemType, quantity)
+	if err != nil {
+		return err
+	}
+	affected, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if affected == 0 {
+		return customErrors.ErrOutOfStock
+	}
+	return nil
+}
+
+// returnStock puts units of a limited item back on sale.
+func (s *service) returnStock(q querier, itemType string, quantity int) error {
+	_, err := q.Exec("UPDATE shop SET stock = stock + $2 WHERE item_type = $1 AND stock IS NOT NULL", itemType, quantity)
+	return err
+}
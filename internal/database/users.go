@@ -0,0 +1,254 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"database/sql"
+	"errors"
+	"strconv"
+	"strings"
+)
+
+// UserStore keeps the user directory.
+type UserStore interface {
+	// SearchUsers retrieves the active users whose username starts with or is similar to the query,
+	// prefix matches first.
+	SearchUsers(query string, limit int) ([]entities.User, error)
+	// UpdateUserProfile sets the display name and department of the user.
+	UpdateUserProfile(userId int, displayName, department string) (*entities.User, error)
+	// DeactivateUser blocks the login and incoming transfers of the user, stops the scheduled
+	// transfers from and to the user and declines the pending coin requests involving the user.
+	DeactivateUser(userId int) error
+	// ReactivateUser lifts the deactivation of the user.
+	ReactivateUser(userId int) error
+	// IsUserActive reports whether the user exists and is not deactivated.
+	IsUserActive(userId int) (bool, error)
+	// EraseUser deactivates the user and erases their personal data. The username is anonymized
+	// and messages are cleared, the ledger keeps every transaction so balances still add up.
+	EraseUser(userId int) error
+}
+
+const userColumns = "id, username, password, is_admin, display_name, department, created_at, updated_at, deactivated_at, erased_at"
+
+func scanUser(row rowScanner) (*entities.User, error) {
+	var user entities.User
+	var deactivatedAt, erasedAt sql.NullTime
+	err := row.Scan(&user.ID, &user.Username, &user.Password, &user.IsAdmin, &user.DisplayName, &user.Department, &user.CreatedAt, &user.UpdatedAt,
+		&deactivatedAt, &erasedAt)
+	if err != nil {
+		return nil, err
+	}
+	if deactivatedAt.Valid {
+		user.DeactivatedAt = &deactivatedAt.Time
+	}
+	if erasedAt.Valid {
+		user.ErasedAt = &erasedAt.Time
+	}
+	return &user, nil
+}
+
+// likeEscaper escapes the LIKE wildcards of a user supplied pattern.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+// SearchUsers retrieves the active users whose username starts with or is similar to the query,
+// prefix matches first. Similarity uses the pg_trgm default threshold. Deactivated and erased
+// users are left out.
+func (s *service) SearchUsers(query string, limit int) ([]entities.User, error) {
+	rows, err := s.catalogReader().Query("SELECT "+userColumns+` FROM users
+		WHERE deactivated_at IS NULL AND erased_at IS NULL AND (username ILIKE $1 || '%' OR username % $2)
+		ORDER BY username ILIKE $1 || '%' DESC, similarity(username, $2) DESC, username
+		LIMIT $3`, likeEscaper.Replace(query), query, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	users := []entities.User{}
+	for rows.Next() {
+		user, err := scanUser(rows)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, *user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return users, nil
+}
+
+// UpdateUserProfile sets the display name and department of the user.
+func (s *service) UpdateUserProfile(userId int, displayName, department string) (*entities.User, error) {
+	user, err := scanUser(s.db.QueryRow("UPDATE users SET display_name = $1, department = $2, updated_at = $3 WHERE id = $4 RETURNING "+userColumns,
+		displayName, department, s.clock.Now(), userId))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.cache.Delete(userCacheKey(user.Username))
+	return user, nil
+}
+
+// DeactivateUser blocks the login and incoming transfers of the user, stops the scheduled
+// transfers from and to the user and declines the pending coin requests involving the user.
+func (s *service) DeactivateUser(userId int) error {
+	var username string
+	err := s.inTx(func(tx *sql.Tx) error {
+		var err error
+		username, err = s.deactivate(tx, userId)
+		return err
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.Delete(userCacheKey(username))
+	s.cache.Delete(activeCacheKey(userId))
+	return nil
+}
+
+// deactivate deactivates the user within the transaction and returns their username,
+// the caller invalidates the cached user once the transaction commits.
+func (s *service) deactivate(tx *sql.Tx, userId int) (string, error) {
+	now := s.clock.Now()
+	var username string
+	err := tx.QueryRow("UPDATE users SET deactivated_at = COALESCE(deactivated_at, $1), updated_at = $1 WHERE id = $2 RETURNING username",
+		now, userId).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return "", customErrors.ErrNotFound
+	}
+	if err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("UPDATE scheduled_transfers SET active = FALSE, updated_at = $1 WHERE (from_user_id = $2 OR to_user_id = $2) AND active",
+		now, userId); err != nil {
+		return "", err
+	}
+	if _, err := tx.Exec("UPDATE coin_requests SET status = $1, updated_at = $2 WHERE (requester_id = $3 OR payer_id = $3) AND status = $4",
+		entities.CoinRequestDeclined, now, userId, entities.CoinRequestPending); err != nil {
+		return "", err
+	}
+	return username, nil
+}
+
+// ReactivateUser lifts the deactivation of the user.
+func (s *service) ReactivateUser(userId int) error {
+	var username string
+	err := s.db.QueryRow("UPDATE users SET deactivated_at = NULL, updated_at = $1 WHERE id = $2 RETURNING username",
+		s.clock.Now(), userId).Scan(&username)
+	if errors.Is(err, sql.ErrNoRows) {
+		return customErrors.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	s.cache.Delete(userCacheKey(username))
+	s.cache.Delete(activeCacheKey(userId))
+	return nil
+}
+
+// IsUserActive reports whether the user exists and is not deactivated. It is checked on every
+// authenticated request, so the answer is cached and read from the primary; deactivation and
+// reactivation invalidate it, other instances pick the change up within the cache TTL.
+func (s *service) IsUserActive(userId int) (bool, error) {
+	key := activeCacheKey(userId)
+	if cached, ok := s.cache.Get(key); ok {
+		if active, ok := cached.(bool); ok {
+			return active, nil
+		}
+	}
+	var active bool
+	err := s.db.QueryRow("SELECT deactivated_at IS NULL FROM users WHERE id = $1", userId).Scan(&active)
+	if err != nil && !errors.Is(err, sql.ErrNoRows) {
+		return false, err
+	}
+	s.cache.Set(key, active)
+	return active, nil
+}
+
+// EraseUser deactivates the user and erases their personal data. The username is anonymized
+// and messages are cleared, also in the outbox events and the logged webhook deliveries, the
+// ledger keeps every transaction so balances still add up. The audit log is left as is: it is
+// append-only and hash-chained, so its entries keep the old username as evidence of past actions.
+func (s *service) EraseUser(userId int) error {
+	var username string
+	err := s.inTx(func(tx *sql.Tx) error {
+		err := tx.QueryRow("SELECT username FROM users WHERE id = $1 FOR UPDATE", userId).Scan(&username)
+		if errors.Is(err, sql.ErrNoRows) {
+			return customErrors.ErrNotFound
+		}
+		if err != nil {
+			return err
+		}
+		if _, err := s.deactivate(tx, userId); err != nil {
+			return err
+		}
+		// An empty password hash never matches, so the account cannot be logged into again.
+		_, err = tx.Exec("UPDATE users SET username = $1, password = '', display_name = '', department = '', erased_at = $2, updated_at = $2 WHERE id = $3",
+			entities.ErasedUsername(userId), s.clock.Now(), userId)
+		if err != nil {
+			return err
+		}
+		for _, query := range []string{
+			"UPDATE coin_transactions SET message = '' WHERE from_user_id = $1 AND message <> ''",
+			"UPDATE coin_requests SET message = '' WHERE requester_id = $1 AND message <> ''",
+			"UPDATE scheduled_transfers SET message = '' WHERE from_user_id = $1 AND message <> ''",
+		} {
+			if _, err := tx.Exec(query, userId); err != nil {
+				return err
+			}
+		}
+		return scrubEvents(tx, userId)
+	})
+	if err != nil {
+		return err
+	}
+	s.cache.Delete(userCacheKey(username))
+	s.cache.Delete(activeCacheKey(userId))
+	return nil
+}
+
+// scrubEvents replaces the username and removes the messages of the user in the event payloads
+// kept in the outbox and in the webhook deliveries, which embed the whole event under "payload".
+func scrubEvents(tx *sql.Tx, userId int) error {
+	id, erased := strconv.Itoa(userId), entities.ErasedUsername(userId)
+	for _, update := range []struct {
+		query string
+		args  []any
+	}{
+		{`UPDATE outbox_events SET payload = payload || jsonb_build_object('username', $1::text)
+			WHERE event_type = $2 AND payload ->> 'userId' = $3`,
+			[]any{erased, events.UserRegistered, id}},
+		{`UPDATE outbox_events SET payload = payload - 'message'
+			WHERE ((event_type = $1 AND payload ->> 'fromUserId' = $3) OR (event_type = $2 AND payload ->> 'requesterId' = $3))
+			AND payload ->> 'message' IS NOT NULL`,
+			[]any{events.CoinsSent, events.CoinsRequested, id}},
+		{`UPDATE webhook_deliveries SET payload = jsonb_set(payload, '{payload,username}', to_jsonb($1::text))
+			WHERE event_type = $2 AND payload #>> '{payload,userId}' = $3`,
+			[]any{erased, events.UserRegistered, id}},
+		{`UPDATE webhook_deliveries SET payload = payload #- '{payload,message}'
+			WHERE ((event_type = $1 AND payload #>> '{payload,fromUserId}' = $3) OR (event_type = $2 AND payload #>> '{payload,requesterId}' = $3))
+			AND payload #>> '{payload,message}' IS NOT NULL`,
+			[]any{events.CoinsSent, events.CoinsRequested, id}},
+	} {
+		if _, err := tx.Exec(update.query, update.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkActive fails when the sender or the recipient of a transfer is deactivated.
+// Pass the same user twice to check a single wallet.
+func checkActive(q querier, fromUserID, toUserID int) error {
+	var deactivated bool
+	err := q.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE id IN ($1, $2) AND deactivated_at IS NOT NULL)", fromUserID, toUserID).Scan(&deactivated)
+	if err != nil {
+		return err
+	}
+	if deactivated {
+		return customErrors.ErrUserDeactivated
+	}
+	return nil
+}
@@ -0,0 +1,98 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"fmt"
+	"time"
+)
+
+// WalletStore exposes the wallet rules to callers validating requests up front.
+// The daily limits and the balance cap are enforced by transfers and purchases
+// themselves, after the wallets involved are locked, so concurrent requests cannot
+// race past them.
+type WalletStore interface {
+	// CheckTransferAmount checks a single transfer amount against the per-transfer bounds.
+	CheckTransferAmount(amount int) error
+}
+
+// CheckTransferAmount checks a single transfer amount against the per-transfer bounds.
+func (s *service) CheckTransferAmount(amount int) error {
+	if amount < s.wallet.MinTransfer {
+		return fmt.Errorf("%w of %d", customErrors.ErrTransferTooSmall, s.wallet.MinTransfer)
+	}
+	if s.wallet.MaxTransfer > 0 && amount > s.wallet.MaxTransfer {
+		return fmt.Errorf("%w of %d", customErrors.ErrTransferTooLarge, s.wallet.MaxTransfer)
+	}
+	return nil
+}
+
+// checkTransferPolicy checks a transfer against the wallet rules. Both wallets must be
+// locked by the transaction and toBalance read under that lock.
+func (s *service) checkTransferPolicy(q querier, fromUserID, toUserID, toBalance, amount int) error {
+	if err := s.CheckTransferAmount(amount); err != nil {
+		return err
+	}
+	if s.wallet.DailyTransferLimit > 0 {
+		sent, err := sentSince(q, fromUserID, s.dayStart())
+		if err != nil {
+			return err
+		}
+		if sent+amount > s.wallet.DailyTransferLimit {
+			return fmt.Errorf("%w: %d of %d coins left today", customErrors.ErrDailyTransferLimit, max(s.wallet.DailyTransferLimit-sent, 0), s.wallet.DailyTransferLimit)
+		}
+	}
+	return s.checkMaxBalance(q, toUserID, toBalance, amount)
+}
+
+// checkMaxBalance checks that crediting amount keeps the user within the configured balance cap.
+// Transfers, refunds and admin credits all go through it. The wallet of the user must be locked
+// by the transaction and balance read under that lock.
+func (s *service) checkMaxBalance(q querier, userId, balance, amount int) error {
+	if s.wallet.MaxBalance == 0 || balance+amount <= s.wallet.MaxBalance {
+		return nil
+	}
+	var username string
+	if err := q.QueryRow("SELECT username FROM users WHERE id = $1", userId).Scan(&username); err != nil {
+		return err
+	}
+	return fmt.Errorf("%w of %d for %s", customErrors.ErrMaxBalance, s.wallet.MaxBalance, username)
+}
+
+// checkPurchasePolicy checks that the user may spend cost in the shop today.
+// The wallet of the user must be locked by the transaction.
+func (s *service) checkPurchasePolicy(q querier, userId, cost int) error {
+	if s.wallet.DailyPurchaseBudget == 0 {
+		return nil
+	}
+	spent, err := spentSince(q, userId, s.dayStart())
+	if err != nil {
+		return err
+	}
+	if spent+cost > s.wallet.DailyPurchaseBudget {
+		return fmt.Errorf("%w: %d of %d coins left today", customErrors.ErrDailyPurchaseBudget, max(s.wallet.DailyPurchaseBudget-spent, 0), s.wallet.DailyPurchaseBudget)
+	}
+	return nil
+}
+
+// dayStart is the start of the current UTC day, in the clock's location as timestamps are stored.
+func (s *service) dayStart() time.Time {
+	now := s.clock.Now()
+	return now.UTC().Truncate(24 * time.Hour).In(now.Location())
+}
+
+// sentSince sums the coins the user sent to other users since the given time.
+func sentSince(q querier, userId int, since time.Time) (int, error) {
+	var sent int
+	err := q.QueryRow("SELECT COALESCE(SUM(amount), 0) FROM coin_transactions WHERE from_user_id = $1 AND transaction_type = $2 AND created_at >= $3",
+		userId, entities.TransactionSend, since).Scan(&sent)
+	return sent, err
+}
+
+// spentSince sums the price of the user's orders placed since the given time, cancelled orders excluded.
+func spentSince(q querier, userId int, since time.Time) (int, error) {
+	var spent int
+	err := q.QueryRow("SELECT COALESCE(SUM(price * quantity), 0) FROM orders WHERE user_id = $1 AND status <> $2 AND created_at >= $3",
+		userId, entities.OrderCancelled, since).Scan(&spent)
+	return spent, err
+}
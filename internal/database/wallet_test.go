@@ -0,0 +1,52 @@
+package database
+
+import (
+	"avitotech/internal/config"
+	"avitotech/internal/customErrors"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCheckTransferAmount(t *testing.T) {
+	srv := &service{wallet: config.Wallet{MinTransfer: 10, MaxTransfer: 500}}
+	tests := []struct {
+		amount int
+		want   error
+	}{
+		{10, nil},
+		{500, nil},
+		{9, customErrors.ErrTransferTooSmall},
+		{-500, customErrors.ErrTransferTooSmall},
+		{501, customErrors.ErrTransferTooLarge},
+	}
+	for _, tt := range tests {
+		if err := srv.CheckTransferAmount(tt.amount); !errors.Is(err, tt.want) {
+			t.Errorf("CheckTransferAmount(%d) = %v, want %v", tt.amount, err, tt.want)
+		}
+	}
+
+	srv.wallet.MaxTransfer = 0
+	if err := srv.CheckTransferAmount(1 << 30); err != nil {
+		t.Errorf("expected no upper bound when MaxTransfer is 0, got %v", err)
+	}
+}
+
+func TestDayStart(t *testing.T) {
+	moscow := time.FixedZone("MSK", 3*60*60)
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2025, 3, 14, 15, 30, 0, 0, time.UTC), time.Date(2025, 3, 14, 0, 0, 0, 0, time.UTC)},
+		// 01:30 in Moscow is still the previous UTC day.
+		{time.Date(2025, 3, 14, 1, 30, 0, 0, moscow), time.Date(2025, 3, 13, 0, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		srv := &service{clock: &manualClock{now: tt.now}}
+		got := srv.dayStart()
+		if !got.Equal(tt.want) || got.Location() != tt.now.Location() {
+			t.Errorf("dayStart() at %v = %v, want %v in %v", tt.now, got, tt.want, tt.now.Location())
+		}
+	}
+}
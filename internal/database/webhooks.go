@@ -0,0 +1,221 @@
+package database
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/entities"
+	"database/sql"
+	"encoding/json"
+	"errors"
+)
+
+// WebhookStore persists webhook subscriptions and their delivery log.
+type WebhookStore interface {
+	// CreateWebhookSubscription inserts a new subscription.
+	CreateWebhookSubscription(sub *entities.WebhookSubscription) error
+	// GetWebhookSubscriptions retrieves all subscriptions.
+	GetWebhookSubscriptions() ([]entities.WebhookSubscription, error)
+	// GetWebhookSubscription retrieves the subscription by the given ID.
+	GetWebhookSubscription(id int) (*entities.WebhookSubscription, error)
+	// UpdateWebhookSubscription updates the URL, event types and active flag of the subscription.
+	UpdateWebhookSubscription(sub *entities.WebhookSubscription) error
+	// DeleteWebhookSubscription deletes the subscription and its delivery log.
+	DeleteWebhookSubscription(id int) error
+	// GetPendingWebhookSubscriptions retrieves the active subscriptions to the event type
+	// that have not yet received the event successfully.
+	GetPendingWebhookSubscriptions(eventType string, eventID int64) ([]entities.WebhookSubscription, error)
+	// RecordWebhookDelivery saves the delivery attempt and updates the failure counter of the subscription,
+	// disabling it after maxFailures consecutive failures. It reports whether the subscription was disabled.
+	RecordWebhookDelivery(delivery *entities.WebhookDelivery, maxFailures int) (bool, error)
+	// GetWebhookDeliveries retrieves the latest deliveries of the subscription.
+	GetWebhookDeliveries(subscriptionID, limit int) ([]entities.WebhookDelivery, error)
+	// GetWebhookDelivery retrieves the delivery by the given ID.
+	GetWebhookDelivery(id int64) (*entities.WebhookDelivery, error)
+}
+
+const webhookSubscriptionColumns = "id, url, event_types, secret, active, consecutive_failures, disabled_at, COALESCE(created_by, 0), created_at, updated_at"
+
+type rowScanner interface {
+	Scan(dest ...any) error
+}
+
+func scanWebhookSubscription(row rowScanner) (*entities.WebhookSubscription, error) {
+	var (
+		sub        entities.WebhookSubscription
+		eventTypes []byte
+		disabledAt sql.NullTime
+	)
+	err := row.Scan(&sub.ID, &sub.URL, &eventTypes, &sub.Secret, &sub.Active, &sub.ConsecutiveFailures, &disabledAt, &sub.CreatedBy, &sub.CreatedAt, &sub.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(eventTypes, &sub.EventTypes); err != nil {
+		return nil, err
+	}
+	if disabledAt.Valid {
+		sub.DisabledAt = &disabledAt.Time
+	}
+	return &sub, nil
+}
+
+func (s *service) queryWebhookSubscriptions(query string, args ...any) ([]entities.WebhookSubscription, error) {
+	rows, err := s.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var subs []entities.WebhookSubscription
+	for rows.Next() {
+		sub, err := scanWebhookSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		subs = append(subs, *sub)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return subs, nil
+}
+
+// CreateWebhookSubscription inserts a new subscription.
+func (s *service) CreateWebhookSubscription(sub *entities.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return err
+	}
+	now := s.clock.Now()
+	sub.Active = true
+	sub.CreatedAt, sub.UpdatedAt = now, now
+	return s.db.QueryRow("INSERT INTO webhook_subscriptions (url, event_types, secret, active, created_by, created_at, updated_at) VALUES ($1, $2, $3, TRUE, NULLIF($4, 0), $5, $5) RETURNING id",
+		sub.URL, eventTypes, sub.Secret, sub.CreatedBy, now).Scan(&sub.ID)
+}
+
+// GetWebhookSubscriptions retrieves all subscriptions.
+func (s *service) GetWebhookSubscriptions() ([]entities.WebhookSubscription, error) {
+	return s.queryWebhookSubscriptions("SELECT " + webhookSubscriptionColumns + " FROM webhook_subscriptions ORDER BY id")
+}
+
+// GetWebhookSubscription retrieves the subscription by the given ID.
+func (s *service) GetWebhookSubscription(id int) (*entities.WebhookSubscription, error) {
+	sub, err := scanWebhookSubscription(s.db.QueryRow("SELECT "+webhookSubscriptionColumns+" FROM webhook_subscriptions WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return sub, err
+}
+
+// UpdateWebhookSubscription updates the URL, event types and active flag of the subscription.
+// Re-enabling a subscription resets its failure counter.
+func (s *service) UpdateWebhookSubscription(sub *entities.WebhookSubscription) error {
+	eventTypes, err := json.Marshal(sub.EventTypes)
+	if err != nil {
+		return err
+	}
+	sub.UpdatedAt = s.clock.Now()
+	res, err := s.db.Exec(`UPDATE webhook_subscriptions SET url = $1, event_types = $2, active = $3, updated_at = $4,
+		consecutive_failures = CASE WHEN $3 AND NOT active THEN 0 ELSE consecutive_failures END,
+		disabled_at = CASE WHEN $3 THEN NULL ELSE COALESCE(disabled_at, $4) END
+		WHERE id = $5`, sub.URL, eventTypes, sub.Active, sub.UpdatedAt, sub.ID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return customErrors.ErrNotFound
+	}
+	return nil
+}
+
+// DeleteWebhookSubscription deletes the subscription and its delivery log.
+func (s *service) DeleteWebhookSubscription(id int) error {
+	res, err := s.db.Exec("DELETE FROM webhook_subscriptions WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 0 {
+		return customErrors.ErrNotFound
+	}
+	return nil
+}
+
+// GetPendingWebhookSubscriptions retrieves the active subscriptions to the event type
+// that have not yet received the event successfully.
+func (s *service) GetPendingWebhookSubscriptions(eventType string, eventID int64) ([]entities.WebhookSubscription, error) {
+	return s.queryWebhookSubscriptions(`SELECT `+webhookSubscriptionColumns+` FROM webhook_subscriptions s
+		WHERE s.active AND s.event_types @> jsonb_build_array($1::text)
+		AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.subscription_id = s.id AND d.event_id = $2 AND d.success)
+		ORDER BY s.id`, eventType, eventID)
+}
+
+// RecordWebhookDelivery saves the delivery attempt and updates the failure counter of the subscription,
+// disabling it after maxFailures consecutive failures. It reports whether the subscription was disabled.
+func (s *service) RecordWebhookDelivery(delivery *entities.WebhookDelivery, maxFailures int) (bool, error) {
+	var disabled bool
+	err := s.inTx(func(tx *sql.Tx) error {
+		delivery.CreatedAt = s.clock.Now()
+		err := tx.QueryRow(`INSERT INTO webhook_deliveries (subscription_id, event_id, event_type, payload, success, status_code, error, duration_ms, created_at)
+			VALUES ($1, $2, $3, $4, $5, NULLIF($6, 0), NULLIF($7, ''), $8, $9) RETURNING id`,
+			delivery.SubscriptionID, delivery.EventID, delivery.EventType, []byte(delivery.Payload), delivery.Success,
+			delivery.StatusCode, delivery.Error, delivery.DurationMs, delivery.CreatedAt).Scan(&delivery.ID)
+		if err != nil {
+			return err
+		}
+		if delivery.Success {
+			_, err = tx.Exec("UPDATE webhook_subscriptions SET consecutive_failures = 0 WHERE id = $1", delivery.SubscriptionID)
+			return err
+		}
+		return tx.QueryRow(`UPDATE webhook_subscriptions SET consecutive_failures = consecutive_failures + 1,
+			active = active AND consecutive_failures + 1 < $2,
+			disabled_at = CASE WHEN active AND consecutive_failures + 1 >= $2 THEN $3 ELSE disabled_at END
+			WHERE id = $1 RETURNING disabled_at IS NOT DISTINCT FROM $3`, delivery.SubscriptionID, maxFailures, delivery.CreatedAt).Scan(&disabled)
+	})
+	return disabled, err
+}
+
+func scanWebhookDelivery(row rowScanner) (*entities.WebhookDelivery, error) {
+	var (
+		delivery   entities.WebhookDelivery
+		payload    []byte
+		statusCode sql.NullInt64
+		errText    sql.NullString
+	)
+	err := row.Scan(&delivery.ID, &delivery.SubscriptionID, &delivery.EventID, &delivery.EventType, &payload, &delivery.Success, &statusCode, &errText, &delivery.DurationMs, &delivery.CreatedAt)
+	if err != nil {
+		return nil, err
+	}
+	delivery.Payload = payload
+	delivery.StatusCode = int(statusCode.Int64)
+	delivery.Error = errText.String
+	return &delivery, nil
+}
+
+const webhookDeliveryColumns = "id, subscription_id, event_id, event_type, payload, success, status_code, error, duration_ms, created_at"
+
+// GetWebhookDeliveries retrieves the latest deliveries of the subscription.
+func (s *service) GetWebhookDeliveries(subscriptionID, limit int) ([]entities.WebhookDelivery, error) {
+	rows, err := s.db.Query("SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE subscription_id = $1 ORDER BY id DESC LIMIT $2", subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var deliveries []entities.WebhookDelivery
+	for rows.Next() {
+		delivery, err := scanWebhookDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		deliveries = append(deliveries, *delivery)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return deliveries, nil
+}
+
+// GetWebhookDelivery retrieves the delivery by the given ID.
+func (s *service) GetWebhookDelivery(id int64) (*entities.WebhookDelivery, error) {
+	delivery, err := scanWebhookDelivery(s.db.QueryRow("SELECT "+webhookDeliveryColumns+" FROM webhook_deliveries WHERE id = $1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, customErrors.ErrNotFound
+	}
+	return delivery, err
+}
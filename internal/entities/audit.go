@@ -0,0 +1,87 @@
+package entities
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"strings"
+	"time"
+)
+
+// Audited actions.
+const (
+	AuditLogin            = "auth.login"
+	AuditLoginFailed      = "auth.login_failed"
+	AuditRegister         = "auth.register"
+	AuditTransfer         = "coins.transfer"
+	AuditBulkTransfer     = "coins.bulk_transfer"
+	AuditCoinRequestPaid  = "coins.request_approved"
+	AuditPurchase         = "shop.purchase"
+	AuditCheckout         = "shop.checkout"
+	AuditRefund           = "order.refund"
+	AuditAdminGrant       = "admin.role_granted"
+	AuditAdminRevoke      = "admin.role_revoked"
+	AuditAdminMint        = "admin.coins_minted"
+	AuditAdminDebit       = "admin.coins_debited"
+	AuditAdminAward       = "admin.coins_awarded"
+	AuditAdminOrderStatus = "admin.order_status_changed"
+	AuditAdminRefund      = "admin.order_refunded"
+	AuditAdminRestock     = "admin.item_restocked"
+	AuditAdminStock       = "admin.item_stock_set"
+	AuditAdminLimit       = "admin.item_limit_set"
+	AuditAdminWebhookAdd  = "admin.webhook_created"
+	AuditAdminWebhookEdit = "admin.webhook_updated"
+	AuditAdminWebhookDel  = "admin.webhook_deleted"
+	AuditAdminRedeliver   = "admin.webhook_redelivered"
+	AuditAdminDeactivate  = "admin.user_deactivated"
+	AuditAdminReactivate  = "admin.user_reactivated"
+	AuditAdminErase       = "admin.user_erased"
+	AuditDataExport       = "user.data_exported"
+)
+
+// AuditGenesisHash is the previous hash of the first entry of the log.
+var AuditGenesisHash = strings.Repeat("0", 64)
+
+// AuditEntry is a record of the append-only audit log.
+// Every entry carries the hash of its predecessor, so editing or removing an entry breaks the chain.
+type AuditEntry struct {
+	ID     int64  `json:"id"`
+	Action string `json:"action"`
+	// ActorID is the user who acted, 0 for anonymous requests and the system.
+	ActorID   int             `json:"actor_id,omitempty"`
+	Target    string          `json:"target,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"user_agent,omitempty"`
+	RequestID string          `json:"request_id,omitempty"`
+	CreatedAt time.Time       `json:"created_at"`
+	PrevHash  string          `json:"prev_hash"`
+	Hash      string          `json:"hash"`
+}
+
+// ComputeHash hashes the entry together with the hash of its predecessor.
+func (e *AuditEntry) ComputeHash() string {
+	payload, _ := json.Marshal([]any{
+		e.PrevHash,
+		e.Action,
+		e.ActorID,
+		e.Target,
+		string(e.Details),
+		e.IP,
+		e.UserAgent,
+		e.RequestID,
+		e.CreatedAt.UTC().Format(time.RFC3339Nano),
+	})
+	sum := sha256.Sum256(payload)
+	return hex.EncodeToString(sum[:])
+}
+
+// AuditFilter narrows down the audit log, zero fields match everything.
+type AuditFilter struct {
+	ActorID  int
+	Action   string
+	Target   string
+	From     time.Time
+	To       time.Time
+	BeforeID int64
+}
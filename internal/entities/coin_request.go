@@ -0,0 +1,32 @@
+package entities
+
+import "time"
+
+type CoinRequestStatus string
+
+const (
+	CoinRequestPending  CoinRequestStatus = "pending"
+	CoinRequestApproved CoinRequestStatus = "approved"
+	CoinRequestDeclined CoinRequestStatus = "declined"
+)
+
+// Valid reports whether the status is one of the known coin request statuses.
+func (s CoinRequestStatus) Valid() bool {
+	switch s {
+	case CoinRequestPending, CoinRequestApproved, CoinRequestDeclined:
+		return true
+	}
+	return false
+}
+
+// CoinRequest is a request of the requester to be paid by the payer.
+type CoinRequest struct {
+	ID          int               `json:"id"`
+	RequesterID int               `json:"requester_id"`
+	PayerID     int               `json:"payer_id"`
+	Amount      int               `json:"amount"`
+	Message     string            `json:"message"`
+	Status      CoinRequestStatus `json:"status"`
+	CreatedAt   time.Time         `json:"created_at"`
+	UpdatedAt   time.Time         `json:"updated_at"`
+}
@@ -0,0 +1,52 @@
+package entities
+
+import (
+	"slices"
+	"time"
+)
+
+type OrderStatus string
+
+const (
+	OrderPlaced         OrderStatus = "placed"
+	OrderReadyForPickup OrderStatus = "ready_for_pickup"
+	OrderDelivered      OrderStatus = "delivered"
+	OrderCancelled      OrderStatus = "cancelled"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderPlaced:         {OrderReadyForPickup, OrderCancelled},
+	OrderReadyForPickup: {OrderDelivered, OrderCancelled},
+}
+
+// CanTransitionTo reports whether an order in this status may move to next.
+func (s OrderStatus) CanTransitionTo(next OrderStatus) bool {
+	return slices.Contains(orderTransitions[s], next)
+}
+
+type Order struct {
+	ID        int         `json:"id"`
+	UserID    int         `json:"user_id"`
+	ItemType  string      `json:"item_type"`
+	Quantity  int         `json:"quantity"`
+	Price     int         `json:"price"`
+	Status    OrderStatus `json:"status"`
+	CreatedAt time.Time   `json:"created_at"`
+	UpdatedAt time.Time   `json:"updated_at"`
+}
+
+// Valid reports whether the status is one of the known order statuses.
+func (s OrderStatus) Valid() bool {
+	switch s {
+	case OrderPlaced, OrderReadyForPickup, OrderDelivered, OrderCancelled:
+		return true
+	}
+	return false
+}
+
+// CartLine is a number of units of one item in a cart.
+type CartLine struct {
+	ItemType string `json:"item_type"`
+	Quantity int    `json:"quantity"`
+}
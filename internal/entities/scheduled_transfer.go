@@ -0,0 +1,30 @@
+package entities
+
+import "time"
+
+// ScheduledTransfer is a coin transfer executed later, once at RunAt or repeatedly by the Cron rule.
+type ScheduledTransfer struct {
+	ID         int        `json:"id"`
+	FromUserID int        `json:"from_user_id"`
+	ToUserID   int        `json:"to_user_id"`
+	Amount     int        `json:"amount"`
+	Message    string     `json:"message"`
+	RunAt      *time.Time `json:"run_at,omitempty"`
+	Cron       string     `json:"cron,omitempty"`
+	// NextRunAt is nil once the transfer is finished or cancelled.
+	NextRunAt           *time.Time `json:"next_run_at,omitempty"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutive_failures"`
+	CreatedAt           time.Time  `json:"created_at"`
+	UpdatedAt           time.Time  `json:"updated_at"`
+}
+
+// ScheduledTransferRun is one execution attempt of a scheduled transfer.
+type ScheduledTransferRun struct {
+	ID                  int64     `json:"id"`
+	ScheduledTransferID int       `json:"scheduled_transfer_id"`
+	ScheduledFor        time.Time `json:"scheduled_for"`
+	ExecutedAt          time.Time `json:"executed_at"`
+	Success             bool      `json:"success"`
+	Error               string    `json:"error,omitempty"`
+}
@@ -0,0 +1,10 @@
+package entities
+
+type ShopItem struct {
+	ItemType string `json:"item_type"`
+	Price    int    `json:"price"`
+	// Stock is the number of units left, nil for unlimited items.
+	Stock *int `json:"stock"`
+	// MaxPerUser is how many units a user may own in active orders, nil for no limit.
+	MaxPerUser *int `json:"max_per_user"`
+}
@@ -0,0 +1,22 @@
+package entities
+
+// LeaderboardEntry is a user's place on a leaderboard.
+type LeaderboardEntry struct {
+	UserID   int    `json:"user_id"`
+	Username string `json:"username"`
+	// Total is the number of coins sent, received or spent.
+	Total int `json:"total"`
+	// Count is the number of transfers or orders behind the total.
+	Count int `json:"count"`
+}
+
+// WalletStats are the all-time totals of a user's wallet.
+type WalletStats struct {
+	Sent     int `json:"sent"`
+	Received int `json:"received"`
+	Spent    int `json:"spent"`
+	// Counterparties is the number of distinct users the user sent coins to or received coins from.
+	Counterparties int `json:"counterparties"`
+	// FavouriteItem is the item the user owns the most units of, empty with an empty inventory.
+	FavouriteItem string `json:"favourite_item,omitempty"`
+}
@@ -1,7 +1,55 @@
 package entities
 
+import (
+	"math"
+	"time"
+)
+
+const (
+	// MaxAmount is the largest number of coins moved by a single transfer, award or adjustment.
+	MaxAmount = 1_000_000_000
+	// MaxBalance is the largest balance a wallet can hold, balances are stored as 32-bit integers.
+	MaxBalance = math.MaxInt32
+)
+
+const (
+	// TransactionSend is a transfer of coins between two users.
+	TransactionSend = "send"
+	// TransactionRefund returns the price of a cancelled order to the buyer.
+	TransactionRefund = "refund"
+	// TransactionMint credits a user with new coins issued by an admin.
+	TransactionMint = "mint"
+	// TransactionDebit takes coins away from a user, e.g. to correct a mistake.
+	TransactionDebit = "debit"
+	// TransactionAward credits a user with new coins as part of a bulk award.
+	TransactionAward = "award"
+)
+
 type Transaction struct {
-	FromUserID int `json:"from_user_id,omitempty"`
-	ToUserID   int `json:"to_user_id,omitempty"`
-	Amount     int `json:"amount,omitempty"`
+	FromUserID int    `json:"from_user_id,omitempty"`
+	ToUserID   int    `json:"to_user_id,omitempty"`
+	Amount     int    `json:"amount,omitempty"`
+	Type       string `json:"transaction_type,omitempty"`
+	OrderID    int    `json:"order_id,omitempty"`
+	Message    string `json:"message,omitempty"`
+	// ActorID is the admin who made a mint, debit or award.
+	ActorID   int       `json:"actor_id,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// ValidAmount reports whether the amount can be moved by a single operation.
+func ValidAmount(amount int) bool {
+	return amount > 0 && amount <= MaxAmount
+}
+
+// CanCredit reports whether the balance can receive the amount without exceeding MaxBalance.
+func CanCredit(balance, amount int) bool {
+	return amount >= 0 && balance <= MaxBalance-amount
+}
+
+// Transfer is one recipient of a bulk transfer.
+type Transfer struct {
+	ToUserID int
+	Amount   int
+	Message  string
 }
@@ -0,0 +1,92 @@
+package entities
+
+import (
+	"math"
+	"math/rand"
+	"reflect"
+	"testing"
+	"testing/quick"
+)
+
+// amountEdges are the interesting amounts around the bounds, mixed into random inputs.
+var amountEdges = []int{math.MinInt, math.MinInt32, -MaxAmount, -1, 0, 1, 2, MaxAmount - 1, MaxAmount, MaxAmount + 1, MaxBalance, MaxBalance + 1, math.MaxInt}
+
+// amountValues generates amounts that hit the edges about half of the time.
+func amountValues(n int) func([]reflect.Value, *rand.Rand) {
+	return func(args []reflect.Value, r *rand.Rand) {
+		for i := 0; i < n; i++ {
+			var v int
+			switch r.Intn(4) {
+			case 0:
+				v = amountEdges[r.Intn(len(amountEdges))]
+			case 1:
+				v = amountEdges[r.Intn(len(amountEdges))] + r.Intn(5) - 2
+			case 2:
+				v = r.Intn(2*MaxAmount) - MaxAmount/2
+			default:
+				v = int(r.Uint64())
+			}
+			args[i] = reflect.ValueOf(v)
+		}
+	}
+}
+
+func TestValidAmountProperty(t *testing.T) {
+	property := func(amount int) bool {
+		return ValidAmount(amount) == (amount >= 1 && amount <= MaxAmount)
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 10000, Values: amountValues(1)}); err != nil {
+		t.Error(err)
+	}
+	for _, amount := range amountEdges {
+		if !property(amount) {
+			t.Errorf("ValidAmount(%d) = %v", amount, ValidAmount(amount))
+		}
+	}
+}
+
+func TestCanCreditProperty(t *testing.T) {
+	// A credit is allowed exactly when the new balance fits in a wallet, computed without overflowing.
+	property := func(balance, amount int) bool {
+		if balance < 0 || balance > MaxBalance {
+			return true
+		}
+		fits := amount >= 0 && int64(balance)+int64(amount) <= MaxBalance && amount <= MaxBalance
+		return CanCredit(balance, amount) == fits
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 10000, Values: amountValues(2)}); err != nil {
+		t.Error(err)
+	}
+	for _, balance := range []int{0, 1, MaxAmount, MaxBalance - 1, MaxBalance} {
+		for _, amount := range amountEdges {
+			if !property(balance, amount) {
+				t.Errorf("CanCredit(%d, %d) = %v", balance, amount, CanCredit(balance, amount))
+			}
+		}
+	}
+}
+
+func TestTransferConservesCoinsProperty(t *testing.T) {
+	// Any transfer accepted by the checks keeps both balances within the wallet range and conserves the total.
+	property := func(from, to, amount int) bool {
+		from, to = abs(from)%(MaxBalance+1), abs(to)%(MaxBalance+1)
+		if !ValidAmount(amount) || from < amount || !CanCredit(to, amount) {
+			return true
+		}
+		newFrom, newTo := from-amount, to+amount
+		return newFrom >= 0 && newTo <= MaxBalance && newFrom+newTo == from+to
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 10000, Values: amountValues(3)}); err != nil {
+		t.Error(err)
+	}
+}
+
+func abs(v int) int {
+	if v < 0 {
+		if v == math.MinInt {
+			return math.MaxInt
+		}
+		return -v
+	}
+	return v
+}
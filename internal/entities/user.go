@@ -1,11 +1,41 @@
 package entities
 
-import "time"
+import (
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErasedUsernamePrefix starts the username of an erased user, it cannot be registered.
+const ErasedUsernamePrefix = "erased-user-"
 
 type User struct {
-	ID        int       `json:"id"`
-	Username  string    `json:"username"`
-	Password  string    `json:"-"`
-	CreatedAt time.Time `json:"created_at"`
-	UpdatedAt time.Time `json:"updated_at"`
+	ID       int    `json:"id"`
+	Username string `json:"username"`
+	Password string `json:"-"`
+	IsAdmin  bool   `json:"is_admin"`
+	// DisplayName and Department are optional profile fields shown in the user directory.
+	DisplayName string    `json:"display_name,omitempty"`
+	Department  string    `json:"department,omitempty"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+	// DeactivatedAt is set while the user cannot log in or receive coins.
+	DeactivatedAt *time.Time `json:"deactivated_at,omitempty"`
+	// ErasedAt is set once the personal data of the user was erased, erased users stay deactivated.
+	ErasedAt *time.Time `json:"erased_at,omitempty"`
+}
+
+// Deactivated reports whether the user was deactivated.
+func (u *User) Deactivated() bool {
+	return u.DeactivatedAt != nil
+}
+
+// ErasedUsername is the anonymous username given to the erased user with the given ID.
+func ErasedUsername(userId int) string {
+	return ErasedUsernamePrefix + strconv.Itoa(userId)
+}
+
+// IsReservedUsername reports whether the username is kept for erased users.
+func IsReservedUsername(username string) bool {
+	return strings.HasPrefix(username, ErasedUsernamePrefix)
 }
@@ -0,0 +1,72 @@
+package events
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// Type identifies a domain event.
+type Type string
+
+const (
+	UserRegistered Type = "user.registered"
+	CoinsSent      Type = "coins.sent"
+	ItemPurchased  Type = "item.purchased"
+
+	OrderStatusChanged Type = "order.status_changed"
+
+	CoinsRequested Type = "coins.requested"
+)
+
+// Event is a domain event stored in the outbox.
+type Event struct {
+	ID         int64           `json:"id"`
+	Type       Type            `json:"type"`
+	OccurredAt time.Time       `json:"occurredAt"`
+	Payload    json.RawMessage `json:"payload"`
+	Attempts   int             `json:"-"`
+	// DeliveredSinks names the sinks that already accepted the event.
+	DeliveredSinks []string `json:"-"`
+}
+
+// UserRegisteredPayload is the payload of UserRegistered.
+type UserRegisteredPayload struct {
+	UserID   int    `json:"userId"`
+	Username string `json:"username"`
+}
+
+// CoinsSentPayload is the payload of CoinsSent.
+type CoinsSentPayload struct {
+	FromUserID int    `json:"fromUserId"`
+	ToUserID   int    `json:"toUserId"`
+	Amount     int    `json:"amount"`
+	Message    string `json:"message,omitempty"`
+}
+
+// ItemPurchasedPayload is the payload of ItemPurchased.
+type ItemPurchasedPayload struct {
+	OrderID  int    `json:"orderId"`
+	UserID   int    `json:"userId"`
+	ItemType string `json:"itemType"`
+	Quantity int    `json:"quantity"`
+	// Price is the price of one unit.
+	Price int `json:"price"`
+}
+
+// OrderStatusChangedPayload is the payload of OrderStatusChanged.
+type OrderStatusChangedPayload struct {
+	OrderID    int    `json:"orderId"`
+	UserID     int    `json:"userId"`
+	ItemType   string `json:"itemType"`
+	FromStatus string `json:"fromStatus"`
+	ToStatus   string `json:"toStatus"`
+}
+
+// CoinsRequestedPayload is the payload of CoinsRequested.
+type CoinsRequestedPayload struct {
+	RequestID   int    `json:"requestId"`
+	RequesterID int    `json:"requesterId"`
+	PayerID     int    `json:"payerId"`
+	Amount      int    `json:"amount"`
+	Message     string `json:"message,omitempty"`
+}
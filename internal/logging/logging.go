@@ -0,0 +1,27 @@
+// Package logging carries a request-scoped logger through the context.
+package logging
+
+import (
+	"context"
+	"log/slog"
+)
+
+type loggerKey struct{}
+
+// WithLogger returns a copy of ctx carrying the logger.
+func WithLogger(ctx context.Context, logger *slog.Logger) context.Context {
+	return context.WithValue(ctx, loggerKey{}, logger)
+}
+
+// FromContext returns the logger of ctx, or fallback outside of a request.
+func FromContext(ctx context.Context, fallback *slog.Logger) *slog.Logger {
+	if logger, ok := ctx.Value(loggerKey{}).(*slog.Logger); ok {
+		return logger
+	}
+	return fallback
+}
+
+// With returns a copy of ctx whose logger carries the extra attributes.
+func With(ctx context.Context, fallback *slog.Logger, args ...any) context.Context {
+	return WithLogger(ctx, FromContext(ctx, fallback).With(args...))
+}
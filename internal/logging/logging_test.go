@@ -0,0 +1,28 @@
+package logging
+
+import (
+	"bytes"
+	"context"
+	"log/slog"
+	"strings"
+	"testing"
+)
+
+func TestFromContext(t *testing.T) {
+	fallback := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if got := FromContext(context.Background(), fallback); got != fallback {
+		t.Fatalf("FromContext() without a logger = %v, want the fallback", got)
+	}
+
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	ctx := With(WithLogger(context.Background(), logger), fallback, "request_id", "abc")
+	ctx = With(ctx, fallback, "user_id", 7)
+	FromContext(ctx, fallback).Info("hello")
+
+	for _, attr := range []string{"request_id=abc", "user_id=7"} {
+		if !strings.Contains(buf.String(), attr) {
+			t.Errorf("log line %q does not contain %q", buf.String(), attr)
+		}
+	}
+}
@@ -0,0 +1,20 @@
+package models
+
+// AdjustCoinsRequest struct for AdjustCoinsRequest
+type AdjustCoinsRequest struct {
+	Amount int    `json:"amount" binding:"required,min=1,max=1000000000"`
+	Reason string `json:"reason" binding:"max=255"`
+}
+
+// BalanceResponse struct for BalanceResponse
+type BalanceResponse struct {
+	User  string `json:"user"`
+	Coins int    `json:"coins"`
+}
+
+// AwardResponse struct for AwardResponse
+type AwardResponse struct {
+	// Awarded is the number of users credited.
+	Awarded int `json:"awarded"`
+	Total   int `json:"total"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+)
+
+// AuditEntryResponse struct for AuditEntryResponse
+type AuditEntryResponse struct {
+	ID        int64           `json:"id"`
+	Action    string          `json:"action"`
+	ActorID   int             `json:"actorId,omitempty"`
+	Actor     string          `json:"actor,omitempty"`
+	Target    string          `json:"target,omitempty"`
+	Details   json.RawMessage `json:"details,omitempty"`
+	IP        string          `json:"ip,omitempty"`
+	UserAgent string          `json:"userAgent,omitempty"`
+	RequestID string          `json:"requestId,omitempty"`
+	CreatedAt time.Time       `json:"createdAt"`
+	PrevHash  string          `json:"prevHash"`
+	Hash      string          `json:"hash"`
+}
+
+// AuditVerifyResponse struct for AuditVerifyResponse
+type AuditVerifyResponse struct {
+	Valid   bool  `json:"valid"`
+	Checked int64 `json:"checked"`
+	// BrokenAt is the ID of the first entry whose hash does not match.
+	BrokenAt int64 `json:"brokenAt,omitempty"`
+}
@@ -8,12 +8,3 @@ type AuthRequest struct {
 type AuthResponse struct {
 	Token string `json:"token"`
 }
-
-type ErrorResponse struct {
-	Error string `json:"error"`
-}
-
-// NewErrorResponse creates a new ErrorResponse instance.
-func NewErrorResponse(err error) *ErrorResponse {
-	return &ErrorResponse{Error: err.Error()}
-}
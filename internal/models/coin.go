@@ -1,7 +1,54 @@
 package models
 
+import "time"
+
 // SendCoinRequest struct for SendCoinRequest
 type SendCoinRequest struct {
-	ToUser string `json:"toUser" binding:"required"`
-	Amount int    `json:"amount" binding:"required"`
+	ToUser  string `json:"toUser" binding:"required"`
+	Amount  int    `json:"amount" binding:"required,min=1,max=1000000000"`
+	Message string `json:"message" binding:"max=255"`
+}
+
+// BulkSendCoinRequest struct for BulkSendCoinRequest
+type BulkSendCoinRequest struct {
+	Transfers []SendCoinRequest `json:"transfers" binding:"required,min=1,max=100,dive"`
+}
+
+// BulkTransferResult is the outcome of one recipient of a bulk transfer.
+type BulkTransferResult struct {
+	ToUser string `json:"toUser"`
+	Amount int    `json:"amount"`
+	// Status is "sent" when the transfer went through and "rejected" otherwise.
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+// BulkSendCoinResponse struct for BulkSendCoinResponse
+type BulkSendCoinResponse struct {
+	Transfers []BulkTransferResult `json:"transfers"`
+}
+
+const (
+	BulkTransferSent     = "sent"
+	BulkTransferRejected = "rejected"
+)
+
+// RequestCoinsRequest struct for RequestCoinsRequest
+type RequestCoinsRequest struct {
+	FromUser string `json:"fromUser" binding:"required"`
+	Amount   int    `json:"amount" binding:"required,min=1,max=1000000000"`
+	Message  string `json:"message" binding:"max=255"`
+}
+
+// CoinRequestResponse struct for CoinRequestResponse
+type CoinRequestResponse struct {
+	ID int `json:"id"`
+	// FromUser is asked to pay ToUser.
+	FromUser  string    `json:"fromUser"`
+	ToUser    string    `json:"toUser"`
+	Amount    int       `json:"amount"`
+	Message   string    `json:"message,omitempty"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
 }
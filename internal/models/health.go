@@ -0,0 +1,21 @@
+package models
+
+// HealthResponse struct for HealthResponse
+type HealthResponse struct {
+	Status string `json:"status"`
+	// Database is reported to admins only.
+	Database *HealthDatabase `json:"database,omitempty"`
+}
+
+// HealthDatabase struct for HealthDatabase
+type HealthDatabase struct {
+	MaxOpenConnections int   `json:"maxOpenConnections"`
+	OpenConnections    int   `json:"openConnections"`
+	InUse              int   `json:"inUse"`
+	Idle               int   `json:"idle"`
+	WaitCount          int64 `json:"waitCount"`
+	WaitDurationMs     int64 `json:"waitDurationMs"`
+	MaxIdleClosed      int64 `json:"maxIdleClosed"`
+	MaxIdleTimeClosed  int64 `json:"maxIdleTimeClosed"`
+	MaxLifetimeClosed  int64 `json:"maxLifetimeClosed"`
+}
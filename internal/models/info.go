@@ -15,14 +15,19 @@ type InfoResponseCoinHistory struct {
 
 // InfoResponseCoinHistoryReceived struct for InfoResponseCoinHistoryReceived
 type InfoResponseCoinHistoryReceived struct {
-	FromUser string `json:"fromUser"`
+	Type     string `json:"type"`
+	FromUser string `json:"fromUser,omitempty"`
 	Amount   int    `json:"amount"`
+	Message  string `json:"message,omitempty"`
+	OrderID  int    `json:"orderId,omitempty"`
 }
 
 // InfoResponseCoinHistorySent struct for InfoResponseCoinHistorySent
 type InfoResponseCoinHistorySent struct {
-	ToUser string `json:"toUser"`
-	Amount int    `json:"amount"`
+	Type    string `json:"type"`
+	ToUser  string `json:"toUser,omitempty"`
+	Amount  int    `json:"amount"`
+	Message string `json:"message,omitempty"`
 }
 
 // InfoResponseInventory struct for InfoResponseInventory
@@ -0,0 +1,20 @@
+package models
+
+import "time"
+
+// OrderResponse struct for OrderResponse
+type OrderResponse struct {
+	ID        int       `json:"id"`
+	UserID    int       `json:"userId"`
+	ItemType  string    `json:"itemType"`
+	Quantity  int       `json:"quantity"`
+	Price     int       `json:"price"`
+	Status    string    `json:"status"`
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+}
+
+// UpdateOrderStatusRequest struct for UpdateOrderStatusRequest
+type UpdateOrderStatusRequest struct {
+	Status string `json:"status" binding:"required,oneof=placed ready_for_pickup delivered cancelled"`
+}
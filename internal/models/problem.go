@@ -0,0 +1,20 @@
+package models
+
+// ProblemContentType is the media type of RFC 7807 problem details.
+const ProblemContentType = "application/problem+json"
+
+// ProblemTypePrefix starts the type URI of every problem, the error code follows it.
+const ProblemTypePrefix = "urn:avitotech:problem:"
+
+// Problem struct for Problem, RFC 7807 problem details extended with the error code.
+type Problem struct {
+	Type     string         `json:"type"`
+	Title    string         `json:"title"`
+	Status   int            `json:"status"`
+	Detail   string         `json:"detail,omitempty"`
+	Instance string         `json:"instance,omitempty"`
+	Code     string         `json:"code"`
+	Details  map[string]any `json:"details,omitempty"`
+	// Error repeats Detail for clients of the former {"error": "..."} body.
+	Error string `json:"error"`
+}
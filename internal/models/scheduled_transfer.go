@@ -0,0 +1,36 @@
+package models
+
+import "time"
+
+// ScheduledTransferRequest struct for ScheduledTransferRequest
+type ScheduledTransferRequest struct {
+	ToUser  string `json:"toUser" binding:"required"`
+	Amount  int    `json:"amount" binding:"required,min=1,max=1000000000"`
+	Message string `json:"message" binding:"max=255"`
+	// Exactly one of RunAt and Cron is set.
+	RunAt *time.Time `json:"runAt"`
+	Cron  string     `json:"cron" binding:"max=100"`
+}
+
+// ScheduledTransferResponse struct for ScheduledTransferResponse
+type ScheduledTransferResponse struct {
+	ID                  int        `json:"id"`
+	ToUser              string     `json:"toUser"`
+	Amount              int        `json:"amount"`
+	Message             string     `json:"message,omitempty"`
+	RunAt               *time.Time `json:"runAt,omitempty"`
+	Cron                string     `json:"cron,omitempty"`
+	NextRunAt           *time.Time `json:"nextRunAt,omitempty"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	CreatedAt           time.Time  `json:"createdAt"`
+}
+
+// ScheduledTransferRunResponse struct for ScheduledTransferRunResponse
+type ScheduledTransferRunResponse struct {
+	ID           int64     `json:"id"`
+	ScheduledFor time.Time `json:"scheduledFor"`
+	ExecutedAt   time.Time `json:"executedAt"`
+	Success      bool      `json:"success"`
+	Error        string    `json:"error,omitempty"`
+}
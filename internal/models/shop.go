@@ -0,0 +1,57 @@
+package models
+
+// ShopResponse struct for ShopResponse
+type ShopResponse struct {
+	Items []ShopResponseItem `json:"items"`
+}
+
+// ShopResponseItem struct for ShopResponseItem
+type ShopResponseItem struct {
+	Type  string `json:"type"`
+	Price int    `json:"price"`
+	// Stock is omitted for unlimited items.
+	Stock *int `json:"stock,omitempty"`
+	// MaxPerUser is omitted when a user may buy any number of units.
+	MaxPerUser *int `json:"maxPerUser,omitempty"`
+}
+
+// RestockRequest struct for RestockRequest
+type RestockRequest struct {
+	Quantity int `json:"quantity" binding:"required,min=1,max=1000000000"`
+}
+
+// SetStockRequest struct for SetStockRequest
+type SetStockRequest struct {
+	// Stock makes the item unlimited when null.
+	Stock *int `json:"stock" binding:"omitempty,min=0,max=1000000000"`
+}
+
+// SetPurchaseLimitRequest struct for SetPurchaseLimitRequest
+type SetPurchaseLimitRequest struct {
+	// MaxPerUser removes the limit when null.
+	MaxPerUser *int `json:"maxPerUser" binding:"omitempty,min=1"`
+}
+
+// CartLine struct for CartLine
+type CartLine struct {
+	Item     string `json:"item" binding:"required"`
+	Quantity int    `json:"quantity" binding:"required,min=1,max=1000"`
+}
+
+// CheckoutRequest struct for CheckoutRequest
+type CheckoutRequest struct {
+	Items []CartLine `json:"items" binding:"required,min=1,max=100,dive"`
+}
+
+// CheckoutResponse struct for CheckoutResponse
+type CheckoutResponse struct {
+	Orders []OrderResponse `json:"orders"`
+	Total  int             `json:"total"`
+}
+
+// PurchaseRequest struct for PurchaseRequest
+type PurchaseRequest struct {
+	Item string `json:"item" binding:"required"`
+	// Quantity defaults to 1.
+	Quantity int `json:"quantity" binding:"omitempty,min=1,max=1000"`
+}
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// LeaderboardEntry struct for LeaderboardEntry
+type LeaderboardEntry struct {
+	Rank     int    `json:"rank"`
+	Username string `json:"username"`
+	Total    int    `json:"total"`
+	Count    int    `json:"count"`
+}
+
+// LeaderboardResponse struct for LeaderboardResponse
+type LeaderboardResponse struct {
+	Window string `json:"window"`
+	// Since is the start of the window, omitted for the all-time leaderboard.
+	Since     *time.Time         `json:"since,omitempty"`
+	Senders   []LeaderboardEntry `json:"senders"`
+	Receivers []LeaderboardEntry `json:"receivers"`
+	Spenders  []LeaderboardEntry `json:"spenders"`
+}
+
+// MyStatsResponse struct for MyStatsResponse
+type MyStatsResponse struct {
+	Sent           int    `json:"sent"`
+	Received       int    `json:"received"`
+	Spent          int    `json:"spent"`
+	Counterparties int    `json:"counterparties"`
+	FavouriteItem  string `json:"favouriteItem,omitempty"`
+}
@@ -0,0 +1,49 @@
+package models
+
+import "time"
+
+// UserProfileResponse struct for UserProfileResponse
+type UserProfileResponse struct {
+	Username    string    `json:"username"`
+	DisplayName string    `json:"displayName,omitempty"`
+	Department  string    `json:"department,omitempty"`
+	IsAdmin     bool      `json:"isAdmin"`
+	CreatedAt   time.Time `json:"createdAt"`
+	// DeactivatedAt is set while the account is deactivated.
+	DeactivatedAt *time.Time `json:"deactivatedAt,omitempty"`
+}
+
+// UserSearchResult struct for UserSearchResult
+type UserSearchResult struct {
+	Username    string `json:"username"`
+	DisplayName string `json:"displayName,omitempty"`
+	Department  string `json:"department,omitempty"`
+}
+
+// UpdateProfileRequest struct for UpdateProfileRequest
+// Omitted fields keep their value, an empty string clears the field.
+type UpdateProfileRequest struct {
+	DisplayName *string `json:"displayName" binding:"omitempty,max=100"`
+	Department  *string `json:"department" binding:"omitempty,max=100"`
+}
+
+// DataExportResponse struct for DataExportResponse
+type DataExportResponse struct {
+	ExportedAt   time.Time               `json:"exportedAt"`
+	Profile      UserProfileResponse     `json:"profile"`
+	Coins        int                     `json:"coins"`
+	Inventory    []InfoResponseInventory `json:"inventory"`
+	Transactions []ExportTransaction     `json:"transactions"`
+	Orders       []OrderResponse         `json:"orders"`
+}
+
+// ExportTransaction struct for ExportTransaction
+type ExportTransaction struct {
+	Type      string    `json:"type"`
+	FromUser  string    `json:"fromUser,omitempty"`
+	ToUser    string    `json:"toUser,omitempty"`
+	Amount    int       `json:"amount"`
+	Message   string    `json:"message,omitempty"`
+	OrderID   int       `json:"orderId,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
@@ -0,0 +1,44 @@
+package models
+
+import "time"
+
+// WebhookSubscriptionRequest struct for WebhookSubscriptionRequest
+type WebhookSubscriptionRequest struct {
+	URL        string   `json:"url" binding:"required,http_url"`
+	EventTypes []string `json:"eventTypes" binding:"required,min=1"`
+	// Secret is generated when empty.
+	Secret string `json:"secret" binding:"omitempty,min=16"`
+}
+
+// UpdateWebhookSubscriptionRequest struct for UpdateWebhookSubscriptionRequest
+type UpdateWebhookSubscriptionRequest struct {
+	URL        *string  `json:"url" binding:"omitempty,http_url"`
+	EventTypes []string `json:"eventTypes" binding:"omitempty,min=1"`
+	Active     *bool    `json:"active"`
+}
+
+// WebhookSubscriptionResponse struct for WebhookSubscriptionResponse
+type WebhookSubscriptionResponse struct {
+	ID                  int        `json:"id"`
+	URL                 string     `json:"url"`
+	EventTypes          []string   `json:"eventTypes"`
+	Active              bool       `json:"active"`
+	ConsecutiveFailures int        `json:"consecutiveFailures"`
+	DisabledAt          *time.Time `json:"disabledAt,omitempty"`
+	CreatedAt           time.Time  `json:"createdAt"`
+	// Secret is returned only when the subscription is created.
+	Secret string `json:"secret,omitempty"`
+}
+
+// WebhookDeliveryResponse struct for WebhookDeliveryResponse
+type WebhookDeliveryResponse struct {
+	ID             int64     `json:"id"`
+	SubscriptionID int       `json:"subscriptionId"`
+	EventID        int64     `json:"eventId"`
+	EventType      string    `json:"eventType"`
+	Success        bool      `json:"success"`
+	StatusCode     int       `json:"statusCode,omitempty"`
+	Error          string    `json:"error,omitempty"`
+	DurationMs     int64     `json:"durationMs"`
+	CreatedAt      time.Time `json:"createdAt"`
+}
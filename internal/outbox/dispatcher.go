@@ -0,0 +1,147 @@
+package outbox
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/events"
+	"avitotech/internal/logging"
+	"avitotech/pkg/clock"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"slices"
+	"time"
+)
+
+// Sink receives dispatched events.
+type Sink interface {
+	// Name identifies the sink in logs.
+	Name() string
+	// Deliver delivers the event, returning an error if it should be retried.
+	Deliver(ctx context.Context, event events.Event) error
+}
+
+// Options tune the dispatcher.
+type Options struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// Lease is how long a claimed event stays invisible to other dispatchers.
+	Lease       time.Duration
+	BaseBackoff time.Duration
+	MaxBackoff  time.Duration
+	// MaxAttempts is how many times an event is tried before it is dead-lettered.
+	MaxAttempts int
+}
+
+// Dispatcher delivers outbox events to sinks with at-least-once semantics:
+// an event is marked delivered only after every sink accepted it, otherwise
+// it is retried with exponential backoff on the sinks that have not accepted it
+// yet. After MaxAttempts failed attempts the event is dead-lettered.
+type Dispatcher struct {
+	store  database.OutboxStore
+	sinks  []Sink
+	clock  clock.Clock
+	logger *slog.Logger
+	opts   Options
+}
+
+func NewDispatcher(store database.OutboxStore, sinks []Sink, clk clock.Clock, logger *slog.Logger, opts Options) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		sinks:  sinks,
+		clock:  clk,
+		logger: logger,
+		opts:   opts,
+	}
+}
+
+// Run dispatches events until ctx is cancelled.
+func (d *Dispatcher) Run(ctx context.Context) {
+	d.logger.Info("Outbox dispatcher started", "sinks", len(d.sinks))
+	ticker := time.NewTicker(d.opts.PollInterval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := d.DispatchBatch(ctx)
+			if err != nil {
+				d.logger.Error("Outbox dispatch", "error", err)
+			}
+			// Keep draining while full batches are available.
+			if err != nil || n < d.opts.BatchSize {
+				break
+			}
+		}
+		select {
+		case <-ctx.Done():
+			d.logger.Info("Outbox dispatcher stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DispatchBatch claims a batch of due events and delivers them, returning the number of claimed events.
+func (d *Dispatcher) DispatchBatch(ctx context.Context) (int, error) {
+	batch, err := d.store.ClaimEvents(d.opts.BatchSize, d.clock.Now().Add(d.opts.Lease))
+	if err != nil {
+		return 0, err
+	}
+	for _, event := range batch {
+		if ctx.Err() != nil {
+			// Unprocessed events become due again when their lease expires.
+			return len(batch), nil
+		}
+		// Sinks log with the event attached, like handlers do with the request.
+		eventCtx := logging.With(ctx, d.logger, "event_id", event.ID, "type", event.Type)
+		if err := d.deliver(eventCtx, event); err != nil {
+			if err := d.fail(eventCtx, event, err); err != nil {
+				return len(batch), err
+			}
+			continue
+		}
+		if err := d.store.MarkEventDelivered(event.ID); err != nil {
+			return len(batch), err
+		}
+	}
+	return len(batch), nil
+}
+
+// deliver sends the event to every sink that has not accepted it yet and records each success.
+func (d *Dispatcher) deliver(ctx context.Context, event events.Event) error {
+	var errs []error
+	for _, sink := range d.sinks {
+		if slices.Contains(event.DeliveredSinks, sink.Name()) {
+			continue
+		}
+		if err := sink.Deliver(ctx, event); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", sink.Name(), err))
+			continue
+		}
+		if err := d.store.MarkSinkDelivered(event.ID, sink.Name()); err != nil {
+			// The sink is tried again with the event, receivers deduplicate by event id.
+			errs = append(errs, fmt.Errorf("%s: record delivery: %w", sink.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// fail schedules the next attempt of the event or dead-letters it once attempts are exhausted.
+func (d *Dispatcher) fail(ctx context.Context, event events.Event, cause error) error {
+	logger := logging.FromContext(ctx, d.logger)
+	if event.Attempts >= d.opts.MaxAttempts {
+		logger.Error("Outbox event dead-lettered", "attempts", event.Attempts, "error", cause)
+		return d.store.MarkEventDead(event.ID, cause.Error())
+	}
+	next := d.clock.Now().Add(d.backoff(event.Attempts))
+	logger.Warn("Outbox event delivery failed", "attempt", event.Attempts, "next_attempt_at", next, "error", cause)
+	return d.store.MarkEventFailed(event.ID, next, cause.Error())
+}
+
+// backoff returns the delay before the next attempt after the given number of attempts.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.opts.BaseBackoff
+	for i := 1; i < attempts && delay < d.opts.MaxBackoff; i++ {
+		delay *= 2
+	}
+	return min(delay, d.opts.MaxBackoff)
+}
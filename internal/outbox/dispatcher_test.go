@@ -0,0 +1,277 @@
+package outbox
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/events"
+	"avitotech/internal/logging"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+type manualClock struct {
+	now time.Time
+}
+
+func (c *manualClock) Now() time.Time {
+	return c.now
+}
+
+type storedEvent struct {
+	event         events.Event
+	nextAttemptAt time.Time
+	delivered     bool
+	dead          bool
+	lastError     string
+}
+
+// fakeStore keeps events in memory and claims them the way the Postgres store does.
+type fakeStore struct {
+	database.OutboxStore
+
+	clock      *manualClock
+	events     []*storedEvent
+	leaseUntil []time.Time
+}
+
+func (f *fakeStore) add(id int64) *storedEvent {
+	stored := &storedEvent{event: events.Event{ID: id, Type: events.CoinsSent}, nextAttemptAt: f.clock.Now()}
+	f.events = append(f.events, stored)
+	return stored
+}
+
+func (f *fakeStore) find(id int64) *storedEvent {
+	for _, stored := range f.events {
+		if stored.event.ID == id {
+			return stored
+		}
+	}
+	return nil
+}
+
+func (f *fakeStore) ClaimEvents(limit int, leaseUntil time.Time) ([]events.Event, error) {
+	f.leaseUntil = append(f.leaseUntil, leaseUntil)
+	var claimed []events.Event
+	for _, stored := range f.events {
+		if len(claimed) == limit || stored.delivered || stored.dead || stored.nextAttemptAt.After(f.clock.Now()) {
+			continue
+		}
+		stored.nextAttemptAt = leaseUntil
+		stored.event.Attempts++
+		event := stored.event
+		event.DeliveredSinks = slices.Clone(stored.event.DeliveredSinks)
+		claimed = append(claimed, event)
+	}
+	return claimed, nil
+}
+
+func (f *fakeStore) MarkEventDelivered(id int64) error {
+	f.find(id).delivered = true
+	return nil
+}
+
+func (f *fakeStore) MarkSinkDelivered(id int64, sink string) error {
+	stored := f.find(id)
+	stored.event.DeliveredSinks = append(stored.event.DeliveredSinks, sink)
+	return nil
+}
+
+func (f *fakeStore) MarkEventFailed(id int64, nextAttemptAt time.Time, reason string) error {
+	stored := f.find(id)
+	stored.nextAttemptAt, stored.lastError = nextAttemptAt, reason
+	return nil
+}
+
+func (f *fakeStore) MarkEventDead(id int64, reason string) error {
+	stored := f.find(id)
+	stored.dead, stored.lastError = true, reason
+	return nil
+}
+
+// fakeSink records delivered event ids and fails while err is set.
+type fakeSink struct {
+	name      string
+	err       error
+	delivered []int64
+}
+
+func (s *fakeSink) Name() string {
+	return s.name
+}
+
+func (s *fakeSink) Deliver(_ context.Context, event events.Event) error {
+	if s.err != nil {
+		return s.err
+	}
+	s.delivered = append(s.delivered, event.ID)
+	return nil
+}
+
+var testOptions = Options{
+	PollInterval: time.Second,
+	BatchSize:    10,
+	Lease:        time.Minute,
+	BaseBackoff:  time.Second,
+	MaxBackoff:   10 * time.Second,
+	MaxAttempts:  5,
+}
+
+func newTestDispatcher(opts Options, sinks ...Sink) (*Dispatcher, *fakeStore, *manualClock) {
+	clk := &manualClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	store := &fakeStore{clock: clk}
+	return NewDispatcher(store, sinks, clk, slog.New(slog.NewTextHandler(io.Discard, nil)), opts), store, clk
+}
+
+func TestDispatchBatchLeasesClaimedEvents(t *testing.T) {
+	sink := &fakeSink{name: "sink", err: errors.New("unavailable")}
+	d, store, clk := newTestDispatcher(testOptions, sink)
+	store.add(1)
+
+	if n, err := d.DispatchBatch(context.Background()); err != nil || n != 1 {
+		t.Fatalf("expected DispatchBatch() to claim 1 event, got (%v, %v)", n, err)
+	}
+	if want := clk.Now().Add(testOptions.Lease); len(store.leaseUntil) != 1 || !store.leaseUntil[0].Equal(want) {
+		t.Fatalf("expected events to be leased until %v, got %v", want, store.leaseUntil)
+	}
+}
+
+func TestDispatchBatchDelivers(t *testing.T) {
+	first, second := &fakeSink{name: "first"}, &fakeSink{name: "second"}
+	d, store, _ := newTestDispatcher(testOptions, first, second)
+	store.add(1)
+	store.add(2)
+
+	if n, err := d.DispatchBatch(context.Background()); err != nil || n != 2 {
+		t.Fatalf("expected DispatchBatch() to claim 2 events, got (%v, %v)", n, err)
+	}
+	for _, sink := range []*fakeSink{first, second} {
+		if !slices.Equal(sink.delivered, []int64{1, 2}) {
+			t.Fatalf("expected sink %s to receive events 1 and 2, got %v", sink.name, sink.delivered)
+		}
+	}
+	for _, stored := range store.events {
+		if !stored.delivered {
+			t.Fatalf("expected event %d to be marked delivered", stored.event.ID)
+		}
+	}
+	if n, err := d.DispatchBatch(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected delivered events not to be claimed again, got (%v, %v)", n, err)
+	}
+}
+
+func TestDispatchBatchRetriesOnlyFailedSinks(t *testing.T) {
+	healthy, failing := &fakeSink{name: "healthy"}, &fakeSink{name: "failing", err: errors.New("unavailable")}
+	d, store, clk := newTestDispatcher(testOptions, healthy, failing)
+	stored := store.add(1)
+
+	if _, err := d.DispatchBatch(context.Background()); err != nil {
+		t.Fatalf("expected DispatchBatch() to return nil, got %v", err)
+	}
+	if stored.delivered || stored.dead {
+		t.Fatal("expected the event to stay pending while a sink fails")
+	}
+	if want := clk.Now().Add(testOptions.BaseBackoff); !stored.nextAttemptAt.Equal(want) {
+		t.Fatalf("expected the next attempt at %v, got %v", want, stored.nextAttemptAt)
+	}
+	if !strings.Contains(stored.lastError, "failing: unavailable") {
+		t.Fatalf("expected the error of the failing sink to be recorded, got %q", stored.lastError)
+	}
+	if !slices.Equal(stored.event.DeliveredSinks, []string{"healthy"}) {
+		t.Fatalf("expected only the healthy sink to be recorded, got %v", stored.event.DeliveredSinks)
+	}
+
+	if n, err := d.DispatchBatch(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected the event not to be retried before its backoff, got (%v, %v)", n, err)
+	}
+
+	failing.err = nil
+	clk.now = stored.nextAttemptAt
+	if _, err := d.DispatchBatch(context.Background()); err != nil {
+		t.Fatalf("expected DispatchBatch() to return nil, got %v", err)
+	}
+	if !stored.delivered {
+		t.Fatal("expected the event to be delivered once every sink accepted it")
+	}
+	if !slices.Equal(healthy.delivered, []int64{1}) {
+		t.Fatalf("expected the healthy sink to receive the event once, got %v", healthy.delivered)
+	}
+	if !slices.Equal(failing.delivered, []int64{1}) {
+		t.Fatalf("expected the failing sink to receive the event on retry, got %v", failing.delivered)
+	}
+}
+
+func TestDispatchBatchDeadLetters(t *testing.T) {
+	opts := testOptions
+	opts.MaxAttempts = 3
+	d, store, clk := newTestDispatcher(opts, &fakeSink{name: "sink", err: errors.New("rejected")})
+	stored := store.add(1)
+
+	for attempt := 1; attempt <= opts.MaxAttempts; attempt++ {
+		if n, err := d.DispatchBatch(context.Background()); err != nil || n != 1 {
+			t.Fatalf("attempt %d: expected DispatchBatch() to claim the event, got (%v, %v)", attempt, n, err)
+		}
+		if dead := attempt == opts.MaxAttempts; stored.dead != dead {
+			t.Fatalf("attempt %d: expected dead to be %v", attempt, dead)
+		}
+		clk.now = clk.now.Add(opts.MaxBackoff)
+	}
+	if !strings.Contains(stored.lastError, "rejected") {
+		t.Fatalf("expected the last error to be kept, got %q", stored.lastError)
+	}
+	if n, err := d.DispatchBatch(context.Background()); err != nil || n != 0 {
+		t.Fatalf("expected a dead-lettered event not to be claimed again, got (%v, %v)", n, err)
+	}
+}
+
+// loggingSink logs through the logger of the delivery context.
+type loggingSink struct{}
+
+func (loggingSink) Name() string {
+	return "logging"
+}
+
+func (loggingSink) Deliver(ctx context.Context, _ events.Event) error {
+	logging.FromContext(ctx, slog.New(slog.NewTextHandler(io.Discard, nil))).Info("Delivered")
+	return nil
+}
+
+func TestDispatchBatchLogsWithEvent(t *testing.T) {
+	var buf bytes.Buffer
+	clk := &manualClock{now: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC)}
+	store := &fakeStore{clock: clk}
+	store.add(7)
+	d := NewDispatcher(store, []Sink{loggingSink{}}, clk, slog.New(slog.NewTextHandler(&buf, nil)), testOptions)
+
+	if _, err := d.DispatchBatch(context.Background()); err != nil {
+		t.Fatalf("expected DispatchBatch() to return nil, got %v", err)
+	}
+	if line := buf.String(); !strings.Contains(line, "event_id=7") || !strings.Contains(line, "type="+string(events.CoinsSent)) {
+		t.Fatalf("expected sinks to log with the event attached, got %q", line)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d, _, _ := newTestDispatcher(testOptions)
+	tests := []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Second},
+		{2, 2 * time.Second},
+		{3, 4 * time.Second},
+		{4, 8 * time.Second},
+		{5, 10 * time.Second},
+		{50, 10 * time.Second},
+	}
+	for _, tt := range tests {
+		if got := d.backoff(tt.attempts); got != tt.want {
+			t.Errorf("backoff(%d) = %v, want %v", tt.attempts, got, tt.want)
+		}
+	}
+}
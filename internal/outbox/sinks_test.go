@@ -0,0 +1,71 @@
+package outbox
+
+import (
+	"avitotech/internal/events"
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+var testEvent = events.Event{
+	ID:         42,
+	Type:       events.CoinsSent,
+	OccurredAt: time.Date(2025, 3, 1, 12, 0, 0, 0, time.UTC),
+	Payload:    json.RawMessage(`{"amount":10}`),
+	Attempts:   2,
+}
+
+func TestHTTPSink(t *testing.T) {
+	var received *http.Request
+	var body []byte
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		received = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer server.Close()
+
+	if err := NewHTTPSink(server.URL, server.Client()).Deliver(context.Background(), testEvent); err != nil {
+		t.Fatalf("expected Deliver() to return nil, got %v", err)
+	}
+	if received.Method != http.MethodPost || received.Header.Get("X-Event-Id") != "42" || received.Header.Get("X-Event-Type") != string(events.CoinsSent) {
+		t.Fatalf("unexpected request %s with headers %v", received.Method, received.Header)
+	}
+	var event events.Event
+	if err := json.Unmarshal(body, &event); err != nil || event.ID != testEvent.ID || string(event.Payload) != string(testEvent.Payload) {
+		t.Fatalf("unexpected body %s (%v)", body, err)
+	}
+}
+
+func TestHTTPSinkRejectsErrorStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	if err := NewHTTPSink(server.URL, server.Client()).Deliver(context.Background(), testEvent); err == nil {
+		t.Fatal("expected Deliver() to fail on a non-2xx status")
+	}
+}
+
+func TestWriterSink(t *testing.T) {
+	var buf bytes.Buffer
+	sink := &WriterSink{w: &buf}
+	for range 2 {
+		if err := sink.Deliver(context.Background(), testEvent); err != nil {
+			t.Fatalf("expected Deliver() to return nil, got %v", err)
+		}
+	}
+	lines := bytes.Split(bytes.TrimSuffix(buf.Bytes(), []byte("\n")), []byte("\n"))
+	if len(lines) != 2 {
+		t.Fatalf("expected one JSON line per event, got %q", buf.String())
+	}
+	var event events.Event
+	if err := json.Unmarshal(lines[0], &event); err != nil || event.ID != testEvent.ID {
+		t.Fatalf("unexpected line %s (%v)", lines[0], err)
+	}
+}
@@ -0,0 +1,157 @@
+package scheduler
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/logging"
+	"avitotech/pkg/clock"
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// Options tune the scheduler.
+type Options struct {
+	PollInterval time.Duration
+	BatchSize    int
+	// LockKey is the Postgres advisory lock key electing the instance that runs transfers.
+	LockKey int64
+	// MaxFailures deactivates a transfer after that many failed runs in a row.
+	MaxFailures int
+}
+
+// Scheduler executes due scheduled transfers. Every API instance runs one, but only
+// the instance holding the advisory lock executes transfers at a time.
+type Scheduler struct {
+	store  database.ScheduledTransferStore
+	clock  clock.Clock
+	logger *slog.Logger
+	opts   Options
+}
+
+func NewScheduler(store database.ScheduledTransferStore, clk clock.Clock, logger *slog.Logger, opts Options) *Scheduler {
+	return &Scheduler{
+		store:  store,
+		clock:  clk,
+		logger: logger,
+		opts:   opts,
+	}
+}
+
+// NextRun returns the first time after the given one matching the cron rule
+// in the standard five-field format or a descriptor such as "@monthly".
+// The rule is evaluated in the location of after, the scheduler uses UTC.
+func NextRun(spec string, after time.Time) (time.Time, error) {
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return time.Time{}, err
+	}
+	next := schedule.Next(after)
+	if next.IsZero() {
+		return time.Time{}, fmt.Errorf("cron rule %q never fires", spec)
+	}
+	return next, nil
+}
+
+// Run executes due transfers while leading, until ctx is cancelled.
+func (s *Scheduler) Run(ctx context.Context) {
+	s.logger.Info("Transfer scheduler started")
+	ticker := time.NewTicker(s.opts.PollInterval)
+	defer ticker.Stop()
+	var lock *database.AdvisoryLock
+	defer func() {
+		if lock != nil {
+			if err := lock.Release(); err != nil {
+				s.logger.Error("Transfer scheduler lock release", "error", err)
+			}
+		}
+	}()
+	for {
+		lock = s.lead(ctx, lock)
+		if lock != nil {
+			for {
+				n, err := s.RunDue(ctx)
+				if err != nil {
+					s.logger.Error("Transfer scheduler run", "error", err)
+				}
+				// Keep draining while full batches are available.
+				if err != nil || n < s.opts.BatchSize || ctx.Err() != nil {
+					break
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			s.logger.Info("Transfer scheduler stopped")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// lead returns the held advisory lock, trying to take it when it is not held or was lost.
+func (s *Scheduler) lead(ctx context.Context, lock *database.AdvisoryLock) *database.AdvisoryLock {
+	if lock != nil {
+		err := lock.Held(ctx)
+		if err == nil {
+			return lock
+		}
+		s.logger.Warn("Transfer scheduler lost leadership", "error", err)
+		// The session is gone, so is the lock, only the connection is left to close.
+		_ = lock.Release()
+	}
+	lock, err := s.store.TryAdvisoryLock(ctx, s.opts.LockKey)
+	if err != nil {
+		s.logger.Error("Transfer scheduler election", "error", err)
+		return nil
+	}
+	if lock != nil {
+		s.logger.Info("Transfer scheduler elected leader")
+	}
+	return lock
+}
+
+// RunDue executes a batch of due transfers, returning the number of due transfers found
+// and the errors of the transfers that could not be executed.
+func (s *Scheduler) RunDue(ctx context.Context) (int, error) {
+	now := s.clock.Now()
+	due, err := s.store.GetDueScheduledTransfers(now, s.opts.BatchSize)
+	if err != nil {
+		return 0, err
+	}
+	// A transfer that cannot be executed does not hold back the rest of the batch.
+	var errs []error
+	for _, transfer := range due {
+		if ctx.Err() != nil {
+			break
+		}
+		if err := s.execute(ctx, &transfer, now); err != nil {
+			errs = append(errs, fmt.Errorf("scheduled transfer %d: %w", transfer.ID, err))
+		}
+	}
+	return len(due), errors.Join(errs...)
+}
+
+func (s *Scheduler) execute(ctx context.Context, transfer *entities.ScheduledTransfer, now time.Time) error {
+	// One-off transfers finish after their run, missed occurrences of recurring ones are skipped.
+	var nextRunAt *time.Time
+	if transfer.Cron != "" {
+		next, err := NextRun(transfer.Cron, now.UTC())
+		if err != nil {
+			return err
+		}
+		nextRunAt = &next
+	}
+	run, err := s.store.ExecuteScheduledTransfer(transfer.ID, *transfer.NextRunAt, nextRunAt, s.opts.MaxFailures)
+	if err != nil {
+		return err
+	}
+	if run != nil && !run.Success {
+		logging.FromContext(ctx, s.logger).Warn("Scheduled transfer failed", "transfer_id", transfer.ID, "from_user_id", transfer.FromUserID, "error", run.Error)
+	}
+	return nil
+}
@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"testing"
+	"time"
+)
+
+type fixedClock time.Time
+
+func (c fixedClock) Now() time.Time {
+	return time.Time(c)
+}
+
+type execution struct {
+	id           int
+	scheduledFor time.Time
+	nextRunAt    *time.Time
+}
+
+// fakeStore returns fixed due transfers and records executions.
+type fakeStore struct {
+	database.ScheduledTransferStore
+
+	due        []entities.ScheduledTransfer
+	executions []execution
+	// errs fails the execution of the transfers with the given ids.
+	errs map[int]error
+}
+
+func (f *fakeStore) GetDueScheduledTransfers(now time.Time, limit int) ([]entities.ScheduledTransfer, error) {
+	return f.due, nil
+}
+
+func (f *fakeStore) ExecuteScheduledTransfer(id int, scheduledFor time.Time, nextRunAt *time.Time, maxFailures int) (*entities.ScheduledTransferRun, error) {
+	f.executions = append(f.executions, execution{id: id, scheduledFor: scheduledFor, nextRunAt: nextRunAt})
+	if err := f.errs[id]; err != nil {
+		return nil, err
+	}
+	return &entities.ScheduledTransferRun{ScheduledTransferID: id, ScheduledFor: scheduledFor, Success: true}, nil
+}
+
+func TestNextRun(t *testing.T) {
+	after := time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{"0 9 1 * *", time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2025, 3, 15, 0, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2025, 3, 14, 10, 45, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		got, err := NextRun(tt.spec, after)
+		if err != nil || !got.Equal(tt.want) {
+			t.Errorf("NextRun(%q) = (%v, %v), want %v", tt.spec, got, err, tt.want)
+		}
+	}
+	if _, err := NextRun("every monday", after); err == nil {
+		t.Errorf("expected NextRun() to reject an invalid rule")
+	}
+}
+
+func TestRunDue(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)
+	// A recurring transfer missed since yesterday and a one-off due a minute ago.
+	missed := now.Add(-24 * time.Hour)
+	dueAt := now.Add(-time.Minute)
+	store := &fakeStore{due: []entities.ScheduledTransfer{
+		{ID: 1, Cron: "0 9 1 * *", NextRunAt: &missed, Active: true},
+		{ID: 2, RunAt: &dueAt, NextRunAt: &dueAt, Active: true},
+	}}
+	s := NewScheduler(store, fixedClock(now), slog.New(slog.NewTextHandler(io.Discard, nil)), Options{BatchSize: 10, MaxFailures: 3})
+
+	n, err := s.RunDue(context.Background())
+	if err != nil || n != 2 {
+		t.Fatalf("expected RunDue() to run 2 transfers, got (%v, %v)", n, err)
+	}
+	if len(store.executions) != 2 {
+		t.Fatalf("expected 2 executions, got %v", store.executions)
+	}
+	recurring := store.executions[0]
+	if !recurring.scheduledFor.Equal(missed) {
+		t.Errorf("expected the run to be scheduled for %v, got %v", missed, recurring.scheduledFor)
+	}
+	if want := time.Date(2025, 4, 1, 9, 0, 0, 0, time.UTC); recurring.nextRunAt == nil || !recurring.nextRunAt.Equal(want) {
+		t.Errorf("expected the recurring transfer to move to %v, got %v", want, recurring.nextRunAt)
+	}
+	if oneOff := store.executions[1]; oneOff.nextRunAt != nil {
+		t.Errorf("expected the one-off transfer to finish, got next run %v", oneOff.nextRunAt)
+	}
+}
+
+func TestRunDueContinuesAfterError(t *testing.T) {
+	now := time.Date(2025, 3, 14, 10, 30, 0, 0, time.UTC)
+	dueAt := now.Add(-time.Minute)
+	broken := errors.New("connection reset")
+	store := &fakeStore{
+		due: []entities.ScheduledTransfer{
+			{ID: 1, RunAt: &dueAt, NextRunAt: &dueAt, Active: true},
+			{ID: 2, RunAt: &dueAt, NextRunAt: &dueAt, Active: true},
+		},
+		errs: map[int]error{1: broken},
+	}
+	s := NewScheduler(store, fixedClock(now), slog.New(slog.NewTextHandler(io.Discard, nil)), Options{BatchSize: 10, MaxFailures: 3})
+
+	n, err := s.RunDue(context.Background())
+	if n != 2 || !errors.Is(err, broken) {
+		t.Fatalf("expected RunDue() to report the failed transfer, got (%v, %v)", n, err)
+	}
+	if len(store.executions) != 2 || store.executions[1].id != 2 {
+		t.Fatalf("expected the next transfer to run after the failed one, got %v", store.executions)
+	}
+}
@@ -0,0 +1,73 @@
+package server
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/models"
+	"fmt"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxAwardUpload is the largest CSV accepted by the award endpoint.
+const maxAwardUpload = 1 << 20
+
+func (s *Server) MintCoinsHandler(c *gin.Context) {
+	var req models.AdjustCoinsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	adminId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.adjustmentService.Mint(c.Request.Context(), adminId, c.Param("username"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) DebitCoinsHandler(c *gin.Context) {
+	var req models.AdjustCoinsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	adminId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.adjustmentService.Debit(c.Request.Context(), adminId, c.Param("username"), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+// AwardCoinsHandler awards coins from a CSV uploaded as the "file" field of a multipart form.
+func (s *Server) AwardCoinsHandler(c *gin.Context) {
+	adminId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, maxAwardUpload)
+	header, err := c.FormFile("file")
+	if err != nil {
+		c.Error(fmt.Errorf("%w: file is required", customErrors.ErrInvalidRequest))
+		return
+	}
+	file, err := header.Open()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	defer file.Close()
+	resp, err := s.adjustmentService.AwardCSV(c.Request.Context(), adminId, file)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,37 @@
+package server
+
+import (
+	"avitotech/internal/service"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) ListAuditLogHandler(c *gin.Context) {
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.auditService.ListEntries(c.Request.Context(), service.AuditQuery{
+		Actor:  c.Query("actor"),
+		Action: c.Query("action"),
+		Target: c.Query("target"),
+		From:   c.Query("from"),
+		To:     c.Query("to"),
+		Before: c.Query("before"),
+	}, limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) VerifyAuditLogHandler(c *gin.Context) {
+	resp, err := s.auditService.Verify()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,67 @@
+package server
+
+import (
+	"avitotech/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) RequestCoinsHandler(c *gin.Context) {
+	var req models.RequestCoinsRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.transactionService.RequestCoins(c.Request.Context(), userId, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) ListCoinRequestsHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.transactionService.ListCoinRequests(c.Request.Context(), userId, c.Query("status"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) ApproveCoinRequestHandler(c *gin.Context) {
+	s.resolveCoinRequest(c, true)
+}
+
+func (s *Server) DeclineCoinRequestHandler(c *gin.Context) {
+	s.resolveCoinRequest(c, false)
+}
+
+func (s *Server) resolveCoinRequest(c *gin.Context, approve bool) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.transactionService.ResolveCoinRequest(c.Request.Context(), userId, int(id), approve)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
@@ -0,0 +1,117 @@
+package server
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/logging"
+	"avitotech/internal/models"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"unicode"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-playground/validator/v10"
+)
+
+// ErrorMiddleware renders the last error recorded with c.Error as an RFC 7807 problem.
+// Domain errors keep their code, status and message, any other error is logged and
+// reported as an internal server error without its message.
+func ErrorMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		if len(c.Errors) == 0 || c.Writer.Written() {
+			return
+		}
+		writeProblem(c, logging.FromContext(c.Request.Context(), logger), c.Errors.Last().Err)
+	}
+}
+
+// RecoveryMiddleware turns panics into an internal server error problem.
+func RecoveryMiddleware(logger *slog.Logger) gin.HandlerFunc {
+	return gin.CustomRecovery(func(c *gin.Context, recovered any) {
+		requestLogger := logging.FromContext(c.Request.Context(), logger)
+		requestLogger.Error("Panic recovered", "panic", recovered)
+		writeProblem(c, requestLogger, customErrors.ErrISE)
+		c.Abort()
+	})
+}
+
+func writeProblem(c *gin.Context, logger *slog.Logger, err error) {
+	domainErr, ok := customErrors.As(err)
+	detail := err.Error()
+	if !ok {
+		logger.Error("Request failed", "error", err)
+		domainErr, detail = customErrors.ErrISE, customErrors.ErrISE.Message
+	} else if domainErr.Status >= http.StatusInternalServerError {
+		logger.Error("Request failed", "error", err)
+	}
+	c.Header("Content-Type", models.ProblemContentType)
+	c.JSON(domainErr.Status, &models.Problem{
+		Type:     models.ProblemTypePrefix + domainErr.Code,
+		Title:    domainErr.Message,
+		Status:   domainErr.Status,
+		Detail:   detail,
+		Instance: c.Request.URL.Path,
+		Code:     domainErr.Code,
+		Details:  customErrors.Details(err),
+		Error:    detail,
+	})
+}
+
+// abortWithError records err for ErrorMiddleware and stops the handler chain.
+func abortWithError(c *gin.Context, err error) {
+	c.Error(err)
+	c.Abort()
+}
+
+// bindJSON binds the request body, recording ErrInvalidRequest with the rejected fields on failure.
+func bindJSON(c *gin.Context, req any) bool {
+	err := c.ShouldBindJSON(req)
+	if err == nil {
+		return true
+	}
+	var validationErrs validator.ValidationErrors
+	if !errors.As(err, &validationErrs) {
+		abortWithError(c, customErrors.ErrInvalidRequest)
+		return false
+	}
+	fields := make(map[string]string, len(validationErrs))
+	for _, fieldErr := range validationErrs {
+		fields[jsonFieldPath(fieldErr.Namespace())] = fieldErr.Tag()
+	}
+	abortWithError(c, customErrors.WithDetails(customErrors.ErrInvalidRequest, map[string]any{"fields": fields}))
+	return false
+}
+
+// jsonFieldPath turns a validator namespace such as "BulkSendCoinRequest.Transfers[0].ToUser" into
+// the path of the field in the request body, "transfers[0].toUser". Request fields are camelCase,
+// a leading acronym is lowercased as a whole, "URL" becomes "url".
+func jsonFieldPath(namespace string) string {
+	segments := strings.Split(namespace, ".")[1:]
+	for i, segment := range segments {
+		runes := []rune(segment)
+		upper := 0
+		for upper < len(runes) && unicode.IsUpper(runes[upper]) {
+			upper++
+		}
+		// In "URLPath" the last capital starts the next word.
+		if upper > 1 && upper < len(runes) {
+			upper--
+		}
+		for j := range upper {
+			runes[j] = unicode.ToLower(runes[j])
+		}
+		segments[i] = string(runes)
+	}
+	return strings.Join(segments, ".")
+}
+
+// currentUserID returns the user authenticated by AuthMiddleware, recording ErrUnauthorized when there is none.
+func currentUserID(c *gin.Context) (int, bool) {
+	userId, ok := c.Keys["userId"].(int)
+	if !ok {
+		abortWithError(c, customErrors.ErrUnauthorized)
+	}
+	return userId, ok
+}
@@ -0,0 +1,83 @@
+package server
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/models"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestErrorMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	logger := slog.New(slog.NewTextHandler(io.Discard, nil))
+	r := gin.New()
+	r.Use(RecoveryMiddleware(logger), ErrorMiddleware(logger))
+	r.GET("/domain", func(c *gin.Context) {
+		c.Error(customErrors.WithDetails(fmt.Errorf("%w: to alice", customErrors.ErrMaxBalance), map[string]any{"limit": 100}))
+	})
+	r.GET("/internal", func(c *gin.Context) {
+		c.Error(errors.New("pq: connection refused"))
+	})
+	r.GET("/panic", func(c *gin.Context) {
+		panic("boom")
+	})
+	r.POST("/bind", func(c *gin.Context) {
+		var req models.SendCoinRequest
+		if bindJSON(c, &req) {
+			c.Status(http.StatusOK)
+		}
+	})
+	r.POST("/webhook", func(c *gin.Context) {
+		var req models.WebhookSubscriptionRequest
+		if bindJSON(c, &req) {
+			c.Status(http.StatusOK)
+		}
+	})
+
+	tests := []struct {
+		method, path, body string
+		status             int
+		code, detail       string
+		details            string
+	}{
+		{"GET", "/domain", "", http.StatusConflict, "max_balance", "recipient balance limit exceeded: to alice", `{"limit":100}`},
+		{"GET", "/internal", "", http.StatusInternalServerError, "internal_error", "internal server error", "null"},
+		{"GET", "/panic", "", http.StatusInternalServerError, "internal_error", "internal server error", "null"},
+		{"POST", "/bind", `{"toUser":"alice","amount":0}`, http.StatusBadRequest, "invalid_request", "invalid request body", `{"fields":{"amount":"required"}}`},
+		{"POST", "/webhook", `{"url":"file:///etc/passwd","eventTypes":["coins.sent"]}`, http.StatusBadRequest, "invalid_request", "invalid request body", `{"fields":{"url":"http_url"}}`},
+		{"POST", "/webhook", `{"url":"gopher://hooks.example","eventTypes":["coins.sent"]}`, http.StatusBadRequest, "invalid_request", "invalid request body", `{"fields":{"url":"http_url"}}`},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if w.Code != tt.status {
+			t.Fatalf("%s: expected status %d, got %d", tt.path, tt.status, w.Code)
+		}
+		if contentType := w.Header().Get("Content-Type"); !strings.HasPrefix(contentType, models.ProblemContentType) {
+			t.Fatalf("%s: expected a problem content type, got %q", tt.path, contentType)
+		}
+		var problem struct {
+			models.Problem
+			Details json.RawMessage `json:"details"`
+		}
+		if err := json.Unmarshal(w.Body.Bytes(), &problem); err != nil {
+			t.Fatalf("%s: unexpected error while decoding the problem: %v", tt.path, err)
+		}
+		if problem.Code != tt.code || problem.Type != models.ProblemTypePrefix+tt.code || problem.Status != tt.status ||
+			problem.Detail != tt.detail || problem.Error != tt.detail || problem.Instance != tt.path {
+			t.Fatalf("%s: unexpected problem %+v", tt.path, problem.Problem)
+		}
+		if details := string(problem.Details); details != tt.details && !(tt.details == "null" && details == "") {
+			t.Fatalf("%s: expected details %s, got %s", tt.path, tt.details, details)
+		}
+	}
+}
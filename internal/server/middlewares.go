@@ -1,41 +1,154 @@
 package server
 
 import (
+	"avitotech/internal/audit"
 	"avitotech/internal/customErrors"
-	"avitotech/internal/models"
+	"avitotech/internal/logging"
+	"avitotech/internal/service"
 	jwt2 "avitotech/pkg/jwt"
+	"crypto/rand"
+	"encoding/hex"
 	"fmt"
 	"github.com/gin-gonic/gin"
 	"log/slog"
-	"net/http"
 	"time"
 )
 
-func AuthMiddleware(secretKey string) gin.HandlerFunc {
+// AuthMiddleware authenticates the bearer token and rejects users deactivated since it was issued.
+func AuthMiddleware(secretKey string, authService service.AuthService, logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
-			c.JSON(http.StatusUnauthorized, models.NewErrorResponse(customErrors.ErrUnauthorized))
-			c.Abort()
+			abortWithError(c, customErrors.ErrUnauthorized)
 			return
 		}
 		jwtParser := jwt2.NewJWTUtil(secretKey)
 		userId, err := jwtParser.ParseUserIdFromToken(authHeader)
 		if err != nil {
-			slog.Warn("Authorization error:", err)
-			c.JSON(http.StatusUnauthorized, models.NewErrorResponse(customErrors.ErrUnauthorized))
-			c.Abort()
+			logging.FromContext(c.Request.Context(), logger).Warn("Authorization error", "error", err)
+			abortWithError(c, customErrors.ErrUnauthorized)
+			return
+		}
+		if err := authService.CheckActive(userId); err != nil {
+			abortWithError(c, err)
 			return
 		}
 		c.Set("userId", userId)
+		c.Request = c.Request.WithContext(logging.With(c.Request.Context(), logger, "user_id", userId))
+		c.Next()
+	}
+}
+
+// AdminMiddleware allows only admins through, it must run after AuthMiddleware.
+func AdminMiddleware(authService service.AuthService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userId, ok := c.Keys["userId"].(int)
+		if !ok {
+			abortWithError(c, customErrors.ErrUnauthorized)
+			return
+		}
+		isAdmin, err := authService.IsAdmin(userId)
+		if err != nil {
+			abortWithError(c, err)
+			return
+		}
+		if !isAdmin {
+			abortWithError(c, customErrors.ErrForbidden)
+			return
+		}
+		c.Next()
+	}
+}
+
+// JSONMiddleware rejects requests whose body is not JSON. Browsers cannot send a cross-site
+// JSON request without a CORS preflight, so this keeps state-changing endpoints CSRF-safe.
+func JSONMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.ContentType() != gin.MIMEJSON {
+			abortWithError(c, customErrors.ErrUnsupportedMedia)
+			return
+		}
+		c.Next()
+	}
+}
+
+// DeprecationMiddleware marks responses of a deprecated route and points clients to its successor.
+func DeprecationMiddleware(successor string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Header("Deprecation", "true")
+		c.Header("Link", fmt.Sprintf("<%s>; rel=\"successor-version\"", successor))
+		c.Next()
+	}
+}
+
+// AuditMiddleware attaches the client details recorded in the audit log to the request context.
+func AuditMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		ctx := audit.WithMeta(c.Request.Context(), audit.Meta{
+			IP:        c.ClientIP(),
+			UserAgent: c.Request.UserAgent(),
+			RequestID: c.GetString(requestIDKey),
+		})
+		c.Request = c.Request.WithContext(ctx)
 		c.Next()
 	}
 }
 
-func LoggerMiddleware() gin.HandlerFunc {
+const (
+	// RequestIDHeader correlates a request with its log lines and audit entries.
+	RequestIDHeader = "X-Request-ID"
+	requestIDKey    = "requestId"
+	maxRequestID    = 128
+)
+
+// RequestIDMiddleware keeps a well-formed X-Request-ID of the client or generates one,
+// and echoes it in the response.
+func RequestIDMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestId := c.GetHeader(RequestIDHeader)
+		if !validRequestID(requestId) {
+			requestId = newRequestID()
+		}
+		c.Set(requestIDKey, requestId)
+		c.Header(RequestIDHeader, requestId)
+		c.Next()
+	}
+}
+
+func validRequestID(id string) bool {
+	if id == "" || len(id) > maxRequestID {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+func newRequestID() string {
+	buf := make([]byte, 16)
+	_, _ = rand.Read(buf)
+	return hex.EncodeToString(buf)
+}
+
+// LoggerMiddleware attaches a logger carrying the request id and route to the request context
+// and writes an access log line once the request is handled. It must run after RequestIDMiddleware.
+func LoggerMiddleware(logger *slog.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
+		requestLogger := logger.With("request_id", c.GetString(requestIDKey), "method", c.Request.Method, "route", c.FullPath())
+		c.Request = c.Request.WithContext(logging.WithLogger(c.Request.Context(), requestLogger))
 		c.Next()
-		slog.Info(fmt.Sprintf("--> [%s] \"%s\" [%d] %s", c.Request.Method, c.Request.URL, c.Writer.Status(), time.Since(start)))
+		logging.FromContext(c.Request.Context(), requestLogger).Info("Request handled",
+			"path", c.Request.URL.Path,
+			"status", c.Writer.Status(),
+			"latency", time.Since(start),
+			"size", c.Writer.Size(),
+		)
 	}
 }
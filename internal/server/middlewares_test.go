@@ -0,0 +1,110 @@
+package server
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/logging"
+	"avitotech/internal/service"
+	jwt2 "avitotech/pkg/jwt"
+	"bytes"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequestIDMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, nil))
+	r := gin.New()
+	r.Use(RequestIDMiddleware(), LoggerMiddleware(logger))
+	r.GET("/items/:id", func(c *gin.Context) {
+		logging.FromContext(c.Request.Context(), nil).Info("Handler")
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		header string
+		keep   bool
+	}{
+		{"trace-42.a:b_c", true},
+		{"", false},
+		{"has space", false},
+		{strings.Repeat("a", maxRequestID+1), false},
+	}
+	for _, tt := range tests {
+		buf.Reset()
+		req := httptest.NewRequest(http.MethodGet, "/items/7", nil)
+		req.Header.Set(RequestIDHeader, tt.header)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+
+		requestId := w.Header().Get(RequestIDHeader)
+		if tt.keep && requestId != tt.header {
+			t.Fatalf("expected request id %q to be kept, got %q", tt.header, requestId)
+		}
+		if !tt.keep && (requestId == tt.header || len(requestId) != 32) {
+			t.Fatalf("expected a generated request id instead of %q, got %q", tt.header, requestId)
+		}
+		lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+		if len(lines) != 2 {
+			t.Fatalf("expected a handler and an access log line, got %q", buf.String())
+		}
+		for _, line := range lines {
+			for _, attr := range []string{"request_id=" + requestId, "route=/items/:id"} {
+				if !strings.Contains(line, attr) {
+					t.Fatalf("log line %q does not contain %q", line, attr)
+				}
+			}
+		}
+		if !strings.Contains(lines[1], "status=204") || !strings.Contains(lines[1], "latency=") {
+			t.Fatalf("access log line %q lacks status or latency", lines[1])
+		}
+	}
+}
+
+type activeAuthService struct {
+	service.AuthService
+	deactivated map[int]bool
+}
+
+func (s activeAuthService) CheckActive(userId int) error {
+	if s.deactivated[userId] {
+		return customErrors.ErrUnauthorized
+	}
+	return nil
+}
+
+func TestAuthMiddlewareRejectsDeactivatedUsers(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	const secret = "secret"
+	r := gin.New()
+	r.Use(ErrorMiddleware(slog.Default()), AuthMiddleware(secret, activeAuthService{deactivated: map[int]bool{2: true}}, slog.Default()))
+	r.GET("/me", func(c *gin.Context) {
+		c.Status(http.StatusNoContent)
+	})
+
+	tests := []struct {
+		userId int
+		status int
+	}{
+		{1, http.StatusNoContent},
+		{2, http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		token, err := jwt2.NewJWTUtil(secret).GenerateToken(tt.userId, "user")
+		if err != nil {
+			t.Fatalf("generate token: %v", err)
+		}
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		if w.Code != tt.status {
+			t.Fatalf("expected status %d for user %d, got %d", tt.status, tt.userId, w.Code)
+		}
+	}
+}
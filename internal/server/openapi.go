@@ -0,0 +1,150 @@
+package server
+
+import (
+	"avitotech/docs"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/logging"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/getkin/kin-openapi/openapi3filter"
+	"github.com/getkin/kin-openapi/routers"
+	"github.com/getkin/kin-openapi/routers/gorillamux"
+	"github.com/gin-gonic/gin"
+)
+
+// swaggerUIPage renders the served spec with Swagger UI loaded from a CDN.
+const swaggerUIPage = `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>API Avito shop</title>
+  <link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body>
+  <div id="swagger-ui"></div>
+  <script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js" crossorigin></script>
+  <script>
+    window.onload = () => {
+      window.ui = SwaggerUIBundle({url: "/api/openapi.yaml", dom_id: "#swagger-ui"});
+    };
+  </script>
+</body>
+</html>
+`
+
+func (s *Server) OpenAPIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "application/yaml", docs.OpenAPI)
+}
+
+func (s *Server) SwaggerUIHandler(c *gin.Context) {
+	c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(swaggerUIPage))
+}
+
+// openAPIValidator checks requests and responses against docs/api.yaml.
+type openAPIValidator struct {
+	router  routers.Router
+	options *openapi3filter.Options
+}
+
+func newOpenAPIValidator() (*openAPIValidator, error) {
+	doc, err := openapi3.NewLoader().LoadFromData(docs.OpenAPI)
+	if err != nil {
+		return nil, fmt.Errorf("load OpenAPI spec: %w", err)
+	}
+	if err := doc.Validate(context.Background()); err != nil {
+		return nil, fmt.Errorf("invalid OpenAPI spec: %w", err)
+	}
+	// Paths are matched on any host, the servers of the spec only describe a local setup.
+	doc.Servers = nil
+	router, err := gorillamux.NewRouter(doc)
+	if err != nil {
+		return nil, fmt.Errorf("build OpenAPI router: %w", err)
+	}
+	return &openAPIValidator{
+		router: router,
+		options: &openapi3filter.Options{
+			// Tokens are checked by AuthMiddleware, the spec only documents the scheme.
+			AuthenticationFunc: openapi3filter.NoopAuthenticationFunc,
+			MultiError:         true,
+			// The handlers apply their own defaults, validation must not rewrite the request.
+			SkipSettingDefaults: true,
+		},
+	}, nil
+}
+
+// validateRequest checks req against the operation it is routed to. A request to a path
+// missing from the spec returns routers.ErrPathNotFound or routers.ErrMethodNotAllowed.
+func (v *openAPIValidator) validateRequest(req *http.Request) (*openapi3filter.RequestValidationInput, error) {
+	route, pathParams, err := v.router.FindRoute(req)
+	if err != nil {
+		return nil, err
+	}
+	input := &openapi3filter.RequestValidationInput{
+		Request:    req,
+		PathParams: pathParams,
+		Route:      route,
+		Options:    v.options,
+	}
+	return input, openapi3filter.ValidateRequest(req.Context(), input)
+}
+
+// validateResponse checks a response to the request described by input.
+func (v *openAPIValidator) validateResponse(input *openapi3filter.RequestValidationInput, status int, header http.Header, body []byte) error {
+	return openapi3filter.ValidateResponse(input.Request.Context(), &openapi3filter.ResponseValidationInput{
+		RequestValidationInput: input,
+		Status:                 status,
+		Header:                 header,
+		Body:                   io.NopCloser(bytes.NewReader(body)),
+		Options:                v.options,
+	})
+}
+
+// OpenAPIValidationMiddleware rejects requests that do not match the OpenAPI spec and logs
+// responses that do not match it. Paths missing from the spec, such as the unversioned aliases,
+// are passed through. It must run before ErrorMiddleware to see the problems it writes.
+func OpenAPIValidationMiddleware(validator *openAPIValidator, logger *slog.Logger) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		input, err := validator.validateRequest(c.Request)
+		if errors.Is(err, routers.ErrPathNotFound) || errors.Is(err, routers.ErrMethodNotAllowed) {
+			c.Next()
+			return
+		}
+		if err != nil {
+			writeProblem(c, logging.FromContext(c.Request.Context(), logger),
+				customErrors.WithDetails(customErrors.ErrInvalidRequest, map[string]any{"openapi": err.Error()}))
+			c.Abort()
+			return
+		}
+
+		recorder := &bodyRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+		if err := validator.validateResponse(input, recorder.Status(), recorder.Header(), recorder.body.Bytes()); err != nil {
+			logging.FromContext(c.Request.Context(), logger).Error("Response does not match the OpenAPI spec",
+				"status", recorder.Status(), "error", err)
+		}
+	}
+}
+
+// bodyRecorder keeps a copy of the response body written through it.
+type bodyRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *bodyRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *bodyRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
@@ -0,0 +1,318 @@
+package server
+
+import (
+	"avitotech/docs"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/models"
+	"avitotech/internal/service"
+	"avitotech/pkg/jwt"
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/getkin/kin-openapi/openapi3"
+	"github.com/gin-gonic/gin"
+)
+
+const contractSecret = "contract-secret"
+
+type contractHealthService struct{}
+
+func (contractHealthService) Check(context.Context) *models.HealthResponse {
+	return &models.HealthResponse{Status: service.HealthStatusOK}
+}
+
+func (contractHealthService) Details(context.Context) *models.HealthResponse {
+	return &models.HealthResponse{Status: service.HealthStatusOK, Database: &models.HealthDatabase{MaxOpenConnections: 25, OpenConnections: 1, Idle: 1}}
+}
+
+type contractAuthService struct{ service.AuthService }
+
+func (contractAuthService) Authenticate(_ context.Context, req *models.AuthRequest) (*models.AuthResponse, error) {
+	if req.Password != "password" {
+		return nil, customErrors.ErrInvalidCredentials
+	}
+	token, err := jwt.NewJWTUtil(contractSecret).GenerateToken(1, req.Username)
+	return &models.AuthResponse{Token: token}, err
+}
+
+func (contractAuthService) IsAdmin(userId int) (bool, error) {
+	return userId == 1, nil
+}
+
+func (contractAuthService) CheckActive(int) error {
+	return nil
+}
+
+type contractInfoService struct{}
+
+func (contractInfoService) GetInfo(context.Context, int) (*models.InfoResponse, error) {
+	return &models.InfoResponse{
+		Coins:     900,
+		Inventory: []models.InfoResponseInventory{{Type: "t-shirt", Quantity: 1}},
+		CoinHistory: models.InfoResponseCoinHistory{
+			Received: []models.InfoResponseCoinHistoryReceived{{Type: "send", FromUser: "bob", Amount: 20}, {Type: "mint", Amount: 100, Message: "bonus"}},
+			Sent:     []models.InfoResponseCoinHistorySent{{Type: "send", ToUser: "carol", Amount: 40, Message: "thanks"}},
+		},
+	}, nil
+}
+
+type contractTransactionService struct{ service.TransactionService }
+
+func (contractTransactionService) SendCoin(_ context.Context, _ int, req *models.SendCoinRequest) error {
+	if req.Amount > 1000 {
+		return customErrors.ErrNotEnoughCoins
+	}
+	return nil
+}
+
+type contractShopService struct{ service.ShopService }
+
+func (contractShopService) ListItems() (*models.ShopResponse, error) {
+	stock := 3
+	return &models.ShopResponse{Items: []models.ShopResponseItem{{Type: "t-shirt", Price: 80}, {Type: "hoody", Price: 300, Stock: &stock}}}, nil
+}
+
+func (contractShopService) BuyItem(_ context.Context, userId int, itemType string, quantity int) (*models.OrderResponse, error) {
+	return contractOrder(7, userId, itemType, quantity), nil
+}
+
+type contractOrderService struct{ service.OrderService }
+
+func (contractOrderService) GetUserOrder(userId, orderId int) (*models.OrderResponse, error) {
+	if orderId != 7 {
+		return nil, customErrors.ErrNotFound
+	}
+	return contractOrder(orderId, userId, "t-shirt", 1), nil
+}
+
+func (contractOrderService) ListOrders(string, int) ([]models.OrderResponse, error) {
+	return []models.OrderResponse{*contractOrder(7, 2, "cup", 2)}, nil
+}
+
+type contractUserService struct{ service.UserService }
+
+func (contractUserService) Me(int) (*models.UserProfileResponse, error) {
+	return &models.UserProfileResponse{Username: "alice", DisplayName: "Alice", IsAdmin: true, CreatedAt: time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)}, nil
+}
+
+type contractStatsService struct{ service.StatsService }
+
+func (contractStatsService) Leaderboard(window string, _ int) (*models.LeaderboardResponse, error) {
+	since := time.Date(2025, 3, 1, 0, 0, 0, 0, time.UTC)
+	return &models.LeaderboardResponse{
+		Window:    window,
+		Since:     &since,
+		Senders:   []models.LeaderboardEntry{{Rank: 1, Username: "alice", Total: 300, Count: 3}},
+		Receivers: []models.LeaderboardEntry{},
+		Spenders:  []models.LeaderboardEntry{},
+	}, nil
+}
+
+func contractOrder(id, userId int, itemType string, quantity int) *models.OrderResponse {
+	createdAt := time.Date(2025, 3, 1, 10, 0, 0, 0, time.UTC)
+	return &models.OrderResponse{
+		ID: id, UserID: userId, ItemType: itemType, Quantity: quantity, Price: 80 * quantity,
+		Status: "placed", CreatedAt: createdAt, UpdatedAt: createdAt,
+	}
+}
+
+func newContractServer() *Server {
+	return &Server{
+		secretKey:      contractSecret,
+		corsOrigins:    []string{"http://localhost:5173"},
+		legacyBuyRoute: true,
+		logger:         slog.New(slog.NewTextHandler(io.Discard, nil)),
+
+		authService:        contractAuthService{},
+		infoService:        contractInfoService{},
+		transactionService: contractTransactionService{},
+		shopService:        contractShopService{},
+		healthService:      contractHealthService{},
+		orderService:       contractOrderService{},
+		statsService:       contractStatsService{},
+		userService:        contractUserService{},
+	}
+}
+
+var ginParam = regexp.MustCompile(`:(\w+)`)
+
+// TestRoutesMatchOpenAPI checks that every /api/v1 route is documented, every documented
+// operation is routed and every /api/v1 route keeps its unversioned alias.
+func TestRoutesMatchOpenAPI(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	engine := newContractServer().RegisterRoutes().(*gin.Engine)
+	doc, err := openapi3.NewLoader().LoadFromData(docs.OpenAPI)
+	if err != nil {
+		t.Fatalf("load spec: %v", err)
+	}
+	var documented []string
+	for path, item := range doc.Paths.Map() {
+		for method := range item.Operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+
+	var routed, aliases []string
+	for _, route := range engine.Routes() {
+		path := ginParam.ReplaceAllString(route.Path, "{$1}")
+		switch {
+		case strings.HasPrefix(path, "/api/v1/"), path == "/api/buy/{item}":
+			routed = append(routed, route.Method+" "+path)
+		case path != "/api/openapi.yaml" && path != "/api/docs":
+			aliases = append(aliases, route.Method+" "+path)
+		}
+	}
+
+	for _, operation := range routed {
+		if !slices.Contains(documented, operation) {
+			t.Errorf("route %s is missing from docs/api.yaml", operation)
+		}
+		if alias := strings.Replace(operation, "/api/v1/", "/api/", 1); alias != operation && !slices.Contains(aliases, alias) {
+			t.Errorf("route %s has no legacy alias %s", operation, alias)
+		}
+	}
+	for _, operation := range documented {
+		if !slices.Contains(routed, operation) {
+			t.Errorf("documented operation %s is not routed", operation)
+		}
+	}
+}
+
+// TestContract sends requests through the router and checks both sides against docs/api.yaml.
+func TestContract(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newContractServer().RegisterRoutes()
+	validator, err := newOpenAPIValidator()
+	if err != nil {
+		t.Fatalf("newOpenAPIValidator() = %v", err)
+	}
+	token, err := jwt.NewJWTUtil(contractSecret).GenerateToken(1, "alice")
+	if err != nil {
+		t.Fatalf("GenerateToken() = %v", err)
+	}
+
+	tests := []struct {
+		name         string
+		method, path string
+		body         string
+		auth         bool
+		status       int
+	}{
+		{"health", "GET", "/api/v1/health", "", false, http.StatusOK},
+		{"auth", "POST", "/api/v1/auth", `{"username":"alice","password":"password"}`, false, http.StatusOK},
+		{"auth with wrong password", "POST", "/api/v1/auth", `{"username":"alice","password":"nope"}`, false, http.StatusUnauthorized},
+		{"info", "GET", "/api/v1/info", "", true, http.StatusOK},
+		{"info without token", "GET", "/api/v1/info", "", false, http.StatusUnauthorized},
+		{"send coins", "POST", "/api/v1/sendCoin", `{"toUser":"bob","amount":10}`, true, http.StatusOK},
+		{"send too many coins", "POST", "/api/v1/sendCoin", `{"toUser":"bob","amount":5000}`, true, http.StatusBadRequest},
+		{"shop", "GET", "/api/v1/shop", "", true, http.StatusOK},
+		{"purchase", "POST", "/api/v1/purchases", `{"item":"t-shirt","quantity":2}`, true, http.StatusCreated},
+		{"order", "GET", "/api/v1/orders/7", "", true, http.StatusOK},
+		{"missing order", "GET", "/api/v1/orders/8", "", true, http.StatusNotFound},
+		{"leaderboard", "GET", "/api/v1/stats/leaderboard?window=week", "", true, http.StatusOK},
+		{"profile", "GET", "/api/v1/users/me", "", true, http.StatusOK},
+		{"admin orders", "GET", "/api/v1/admin/orders?limit=10", "", true, http.StatusOK},
+		{"admin health", "GET", "/api/v1/admin/health", "", true, http.StatusOK},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body))
+			if tt.body != "" {
+				req.Header.Set("Content-Type", gin.MIMEJSON)
+			}
+			if tt.auth {
+				req.Header.Set("Authorization", "Bearer "+token)
+			}
+			input, err := validator.validateRequest(req)
+			if err != nil {
+				t.Fatalf("request does not match the spec: %v", err)
+			}
+
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, req)
+			if w.Code != tt.status {
+				t.Fatalf("expected status %d, got %d: %s", tt.status, w.Code, w.Body)
+			}
+			if err := validator.validateResponse(input, w.Code, w.Header(), w.Body.Bytes()); err != nil {
+				t.Fatalf("response does not match the spec: %v\n%s", err, w.Body)
+			}
+		})
+	}
+}
+
+func TestLegacyAlias(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newContractServer().RegisterRoutes()
+	for _, path := range []string{"/api/health", "/api/v1/health"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("%s: expected status 200, got %d", path, w.Code)
+		}
+	}
+}
+
+func TestLegacyBuyRoute(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	token, err := jwt.NewJWTUtil(contractSecret).GenerateToken(1, "alice")
+	if err != nil {
+		t.Fatalf("GenerateToken() = %v", err)
+	}
+	for _, enabled := range []bool{false, true} {
+		s := newContractServer()
+		s.legacyBuyRoute = enabled
+		req := httptest.NewRequest(http.MethodGet, "/api/buy/t-shirt", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		s.RegisterRoutes().ServeHTTP(w, req)
+
+		if !enabled {
+			if w.Code != http.StatusNotFound {
+				t.Fatalf("expected the route to be unmounted when disabled, got %d", w.Code)
+			}
+			continue
+		}
+		if w.Code != http.StatusOK || w.Header().Get("Deprecation") != "true" {
+			t.Fatalf("expected a deprecated purchase when enabled, got %d with Deprecation %q", w.Code, w.Header().Get("Deprecation"))
+		}
+	}
+}
+
+func TestOpenAPIValidationMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	s := newContractServer()
+	s.openAPIValidation = true
+	handler := s.RegisterRoutes()
+
+	token, err := jwt.NewJWTUtil(contractSecret).GenerateToken(1, "alice")
+	if err != nil {
+		t.Fatalf("GenerateToken() = %v", err)
+	}
+	req := httptest.NewRequest(http.MethodGet, "/api/v1/stats/leaderboard?limit=abc", nil)
+	req.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	handler.ServeHTTP(w, req)
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), `"openapi"`) {
+		t.Fatalf("expected the spec to reject the request, got %d: %s", w.Code, w.Body)
+	}
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	handler := newContractServer().RegisterRoutes()
+	for path, contentType := range map[string]string{"/api/openapi.yaml": "application/yaml", "/api/docs": "text/html"} {
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, httptest.NewRequest(http.MethodGet, path, nil))
+		if w.Code != http.StatusOK || !strings.HasPrefix(w.Header().Get("Content-Type"), contentType) {
+			t.Fatalf("%s: expected %s, got %d %q", path, contentType, w.Code, w.Header().Get("Content-Type"))
+		}
+	}
+}
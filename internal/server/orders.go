@@ -0,0 +1,104 @@
+package server
+
+import (
+	"avitotech/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) ListOrdersHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.orderService.ListUserOrders(userId, c.Query("status"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) GetOrderHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.orderService.GetUserOrder(userId, int(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) AdminListOrdersHandler(c *gin.Context) {
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.orderService.ListOrders(c.Query("status"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) UpdateOrderStatusHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateOrderStatusRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.orderService.UpdateStatus(c.Request.Context(), adminId, int(id), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) RefundOrderHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.orderService.RefundUserOrder(c.Request.Context(), userId, int(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) AdminRefundOrderHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.orderService.RefundOrder(c.Request.Context(), adminId, int(id))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
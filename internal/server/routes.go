@@ -3,126 +3,258 @@ package server
 import (
 	"avitotech/internal/customErrors"
 	"avitotech/internal/models"
-	"errors"
+	"avitotech/internal/service"
 	"fmt"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
-	"log/slog"
 	"net/http"
+	"strconv"
 )
 
+// maxPurchaseQuantity is the largest number of units bought in one line.
+const maxPurchaseQuantity = 1000
+
 func (s *Server) RegisterRoutes() http.Handler {
 	r := gin.New()
-	r.Use(LoggerMiddleware())
-	r.Use(gin.Recovery())
+	r.Use(RequestIDMiddleware())
+	r.Use(LoggerMiddleware(s.logger))
+	r.Use(RecoveryMiddleware(s.logger))
+	if s.openAPIValidation {
+		validator, err := newOpenAPIValidator()
+		if err != nil {
+			s.logger.Error("OpenAPI validation disabled", "error", err)
+		} else {
+			r.Use(OpenAPIValidationMiddleware(validator, s.logger))
+		}
+	}
+	r.Use(ErrorMiddleware(s.logger))
+	r.Use(AuditMiddleware())
 	r.Use(cors.New(cors.Config{
-		AllowOrigins:     []string{"http://localhost:5173"}, // My frontend URL
+		AllowOrigins:     s.corsOrigins,
 		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS", "PATCH"},
-		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type"},
+		AllowHeaders:     []string{"Accept", "Authorization", "Content-Type", RequestIDHeader},
+		ExposeHeaders:    []string{RequestIDHeader},
 		AllowCredentials: true,
 	}))
 
-	r.POST("api/auth", s.AuthHandler)
-
-	r.Use(AuthMiddleware(s.secretKey))
+	r.GET("api/openapi.yaml", s.OpenAPIHandler)
+	r.GET("api/docs", s.SwaggerUIHandler)
 
-	r.GET("api/info", s.InfoHandler)
-	r.POST("api/sendCoin", s.SendCoinHandler)
-	r.GET("api/buy/:item", s.BuyItemHandler)
+	s.registerAPI(r.Group("api/v1"), false)
+	// The unversioned routes predate /api/v1 and stay as aliases for existing clients.
+	s.registerAPI(r.Group("api"), true)
 
 	return r
 }
 
+// registerAPI mounts the API on the group, legacy adds the routes dropped from /api/v1.
+func (s *Server) registerAPI(api *gin.RouterGroup, legacy bool) {
+	api.GET("health", s.HealthHandler)
+	api.POST("auth", s.AuthHandler)
+
+	authed := api.Group("", AuthMiddleware(s.secretKey, s.authService, s.logger))
+	authed.GET("info", s.InfoHandler)
+	authed.POST("sendCoin", s.SendCoinHandler)
+	authed.POST("sendCoin/bulk", s.SendCoinBulkHandler)
+	authed.POST("coinRequests", s.RequestCoinsHandler)
+	authed.GET("coinRequests", s.ListCoinRequestsHandler)
+	authed.POST("coinRequests/:id/approve", s.ApproveCoinRequestHandler)
+	authed.POST("coinRequests/:id/decline", s.DeclineCoinRequestHandler)
+	authed.POST("scheduledTransfers", s.CreateScheduledTransferHandler)
+	authed.GET("scheduledTransfers", s.ListScheduledTransfersHandler)
+	authed.DELETE("scheduledTransfers/:id", s.CancelScheduledTransferHandler)
+	authed.GET("scheduledTransfers/:id/runs", s.ListScheduledTransferRunsHandler)
+	authed.GET("shop", s.ShopHandler)
+	if legacy && s.legacyBuyRoute {
+		authed.GET("buy/:item", DeprecationMiddleware("/api/v1/purchases"), s.BuyItemHandler)
+	}
+	authed.POST("purchases", JSONMiddleware(), s.PurchaseHandler)
+	authed.POST("cart/checkout", JSONMiddleware(), s.CheckoutHandler)
+	authed.GET("orders", s.ListOrdersHandler)
+	authed.GET("orders/:id", s.GetOrderHandler)
+	authed.GET("stats/leaderboard", s.LeaderboardHandler)
+	authed.GET("stats/me", s.MyStatsHandler)
+	authed.GET("users/search", s.SearchUsersHandler)
+	authed.GET("users/me", s.GetMeHandler)
+	authed.PATCH("users/me", JSONMiddleware(), s.UpdateMeHandler)
+	authed.GET("users/me/export", s.ExportMeHandler)
+	authed.POST("orders/:id/refund", s.RefundOrderHandler)
+
+	admin := authed.Group("admin", AdminMiddleware(s.authService))
+	admin.POST("webhooks", s.CreateWebhookHandler)
+	admin.GET("webhooks", s.ListWebhooksHandler)
+	admin.PATCH("webhooks/:id", s.UpdateWebhookHandler)
+	admin.DELETE("webhooks/:id", s.DeleteWebhookHandler)
+	admin.GET("webhooks/:id/deliveries", s.ListWebhookDeliveriesHandler)
+	admin.POST("webhooks/deliveries/:id/redeliver", s.RedeliverWebhookHandler)
+	admin.GET("orders", s.AdminListOrdersHandler)
+	admin.POST("orders/:id/status", s.UpdateOrderStatusHandler)
+	admin.POST("orders/:id/refund", s.AdminRefundOrderHandler)
+	admin.POST("shop/:item/restock", s.RestockHandler)
+	admin.PUT("shop/:item/stock", s.SetStockHandler)
+	admin.PUT("shop/:item/limit", s.SetPurchaseLimitHandler)
+	admin.POST("users/:username/mint", s.MintCoinsHandler)
+	admin.POST("users/:username/debit", s.DebitCoinsHandler)
+	admin.POST("coins/award", s.AwardCoinsHandler)
+	admin.POST("users/:username/deactivate", s.DeactivateUserHandler)
+	admin.POST("users/:username/reactivate", s.ReactivateUserHandler)
+	admin.POST("users/:username/erase", s.EraseUserHandler)
+	admin.GET("audit", s.ListAuditLogHandler)
+	admin.GET("audit/verify", s.VerifyAuditLogHandler)
+	admin.GET("health", s.AdminHealthHandler)
+}
+
+func (s *Server) HealthHandler(c *gin.Context) {
+	writeHealth(c, s.healthService.Check(c.Request.Context()))
+}
+
+// AdminHealthHandler reports the health together with the connection pool statistics.
+func (s *Server) AdminHealthHandler(c *gin.Context) {
+	writeHealth(c, s.healthService.Details(c.Request.Context()))
+}
+
+func writeHealth(c *gin.Context, resp *models.HealthResponse) {
+	if resp.Status != service.HealthStatusOK {
+		c.JSON(http.StatusServiceUnavailable, resp)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
 func (s *Server) AuthHandler(c *gin.Context) {
 	var req models.AuthRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		errResp := models.NewErrorResponse(customErrors.ErrInvalidRequest)
-		c.JSON(http.StatusBadRequest, errResp)
+	if !bindJSON(c, &req) {
 		return
 	}
-	resp, err := s.authService.Authenticate(&req)
+	resp, err := s.authService.Authenticate(c.Request.Context(), &req)
 	if err != nil {
-		slog.Error("Auth handling", "Error", err)
-		if errors.Is(err, customErrors.ErrInvalidCredentials) {
-			c.JSON(http.StatusUnauthorized, models.NewErrorResponse(customErrors.ErrInvalidCredentials))
-		} else {
-			c.JSON(http.StatusInternalServerError, models.NewErrorResponse(customErrors.ErrISE))
-		}
+		c.Error(err)
 		return
 	}
 	c.JSON(http.StatusOK, resp)
 }
 
 func (s *Server) InfoHandler(c *gin.Context) {
-	userId, ok := c.Keys["userId"].(int)
+	userId, ok := currentUserID(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, models.NewErrorResponse(customErrors.ErrInvalidRequest))
 		return
 	}
-	resp, err := s.infoService.GetInfo(userId)
+	resp, err := s.infoService.GetInfo(c.Request.Context(), userId)
 	if err != nil {
-		slog.Error("Info handling", "Error", err)
-		c.JSON(http.StatusInternalServerError, models.NewErrorResponse(customErrors.ErrISE))
+		c.Error(err)
 		return
 	}
-	if resp == nil {
-		c.JSON(http.StatusBadRequest, models.NewErrorResponse(customErrors.ErrNotFound))
-	}
-
 	c.JSON(http.StatusOK, resp)
 }
 
 func (s *Server) SendCoinHandler(c *gin.Context) {
 	var req models.SendCoinRequest
-	if err := c.ShouldBindJSON(&req); err != nil {
-		slog.Error("SendCoin handling", "Error", err)
-		errResp := models.NewErrorResponse(customErrors.ErrInvalidRequest)
-		c.JSON(http.StatusBadRequest, errResp)
+	if !bindJSON(c, &req) {
 		return
 	}
-	userId, ok := c.Keys["userId"].(int)
+	userId, ok := currentUserID(c)
 	if !ok {
-		c.JSON(http.StatusBadRequest, models.NewErrorResponse(customErrors.ErrInvalidRequest))
 		return
 	}
-	err := s.transactionService.SendCoin(userId, &req)
+	if err := s.transactionService.SendCoin(c.Request.Context(), userId, &req); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusOK)
+}
+
+// SendCoinBulkHandler sends a batch of transfers. A rejected batch is a problem whose details
+// explain each recipient.
+func (s *Server) SendCoinBulkHandler(c *gin.Context) {
+	var req models.BulkSendCoinRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.transactionService.SendCoinBulk(c.Request.Context(), userId, &req)
 	if err != nil {
-		slog.Error("SendCoin handling", "Error", err)
-		if errors.Is(err, customErrors.ErrNotEnoughCoins) {
-			c.JSON(http.StatusBadRequest, models.NewErrorResponse(customErrors.ErrNotEnoughCoins))
-		} else if errors.Is(err, customErrors.ErrInvalidUsername) {
-			c.JSON(http.StatusBadRequest, models.NewErrorResponse(customErrors.ErrInvalidUsername))
-		} else {
-			c.JSON(http.StatusInternalServerError, models.NewErrorResponse(customErrors.ErrISE))
-		}
+		c.Error(err)
 		return
 	}
+	c.JSON(http.StatusOK, resp)
+}
 
-	c.Status(http.StatusOK)
+func (s *Server) ShopHandler(c *gin.Context) {
+	resp, err := s.shopService.ListItems()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
 }
 
 func (s *Server) BuyItemHandler(c *gin.Context) {
 	itemType := c.Param("item")
 	if itemType == "" {
-		c.JSON(http.StatusBadRequest, models.NewErrorResponse(fmt.Errorf("item type is required")))
+		c.Error(fmt.Errorf("%w: item type is required", customErrors.ErrInvalidRequest))
 		return
 	}
 
-	userId, ok := c.Keys["userId"].(int)
+	quantity := 1
+	if raw := c.Query("quantity"); raw != "" {
+		var err error
+		quantity, err = strconv.Atoi(raw)
+		if err != nil || quantity <= 0 || quantity > maxPurchaseQuantity {
+			c.Error(fmt.Errorf("%w: quantity must be 1 to %d", customErrors.ErrInvalidRequest, maxPurchaseQuantity))
+			return
+		}
+	}
+
+	userId, ok := currentUserID(c)
 	if !ok {
-		c.JSON(http.StatusUnauthorized, models.NewErrorResponse(customErrors.ErrUnauthorized))
 		return
 	}
 
-	if err := s.shopService.BuyItem(userId, itemType); err != nil {
-		slog.Error("BuyItem handling", "Error", err)
-		if errors.Is(err, customErrors.ErrNotEnoughCoins) || errors.Is(err, customErrors.ErrNotFound) {
-			c.JSON(http.StatusBadRequest, models.NewErrorResponse(err))
-		} else {
-			c.JSON(http.StatusInternalServerError, models.NewErrorResponse(customErrors.ErrISE))
-		}
+	if _, err := s.shopService.BuyItem(c.Request.Context(), userId, itemType, quantity); err != nil {
+		c.Error(err)
 		return
 	}
 
 	c.Status(http.StatusOK)
 }
+
+func (s *Server) PurchaseHandler(c *gin.Context) {
+	var req models.PurchaseRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	if req.Quantity == 0 {
+		req.Quantity = 1
+	}
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.shopService.BuyItem(c.Request.Context(), userId, req.Item, req.Quantity)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Location", fmt.Sprintf("/api/v1/orders/%d", resp.ID))
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) CheckoutHandler(c *gin.Context) {
+	var req models.CheckoutRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.shopService.Checkout(c.Request.Context(), userId, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
@@ -0,0 +1,75 @@
+package server
+
+import (
+	"avitotech/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) CreateScheduledTransferHandler(c *gin.Context) {
+	var req models.ScheduledTransferRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.scheduledTransferService.Create(userId, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) ListScheduledTransfersHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.scheduledTransferService.List(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) CancelScheduledTransferHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	if err := s.scheduledTransferService.Cancel(userId, int(id)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) ListScheduledTransferRunsHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.scheduledTransferService.ListRuns(userId, int(id), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
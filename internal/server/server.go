@@ -1,51 +1,95 @@
 package server
 
 import (
+	"avitotech/internal/audit"
+	"avitotech/internal/config"
 	"avitotech/internal/service"
+	"avitotech/internal/webhooks"
+	"avitotech/pkg/clock"
+	"avitotech/pkg/imcache"
 	"avitotech/pkg/jwt"
-	"fmt"
+	"log/slog"
 	"net/http"
-	"os"
-	"strconv"
 	"time"
 
 	"avitotech/internal/database"
-	_ "github.com/joho/godotenv/autoload"
 )
 
+// Deps are the external dependencies the server is built on.
+// The store is shared with the background workers, so they see the same cache and read-your-writes pins.
+type Deps struct {
+	Store  database.Service
+	Cache  imcache.Cache // optional, an in-memory cache owned by the server is used when nil
+	Clock  clock.Clock
+	Logger *slog.Logger
+}
+
 type Server struct {
-	port      int
-	secretKey string
+	secretKey      string
+	corsOrigins    []string
+	legacyBuyRoute bool
+	// openAPIValidation checks every /api/v1 request and response against the OpenAPI spec.
+	openAPIValidation bool
+	logger            *slog.Logger
 
 	authService        service.AuthService
 	infoService        service.InfoService
 	transactionService service.TransactionService
 	shopService        service.ShopService
+	healthService      service.HealthService
+	webhookService     service.WebhookService
+	orderService       service.OrderService
+	adjustmentService  service.AdjustmentService
+	auditService       service.AuditService
+	statsService       service.StatsService
+	userService        service.UserService
+
+	scheduledTransferService service.ScheduledTransferService
 }
 
-func NewServer() *http.Server {
-	port, _ := strconv.Atoi(os.Getenv("PORT"))
-	db := database.New()
-	secretKey := os.Getenv("JWT_SECRET")
-	jwtUtil := jwt.NewJWTUtil(secretKey)
+// NewServer builds the API handler from the given dependencies.
+// The returned cleanup function releases the resources owned by the server.
+func NewServer(cfg *config.Config, deps Deps) (http.Handler, func()) {
+	var cleanups []func()
+
+	cache := deps.Cache
+	if cache == nil {
+		memCache := imcache.NewInMemoryCache(5 * time.Minute)
+		cleanups = append(cleanups, memCache.Close)
+		cache = memCache
+	}
+
+	db := deps.Store
+	jwtUtil := jwt.NewJWTUtil(cfg.Auth.JWTSecret)
+	auditor := audit.NewRecorder(db, deps.Logger)
+	notifier := webhooks.NewNotifier(db, &http.Client{Timeout: cfg.Webhooks.Timeout}, deps.Clock, deps.Logger, cfg.Webhooks.MaxConsecutiveFailures)
 	NewServer := &Server{
-		port:      port,
-		secretKey: secretKey,
+		secretKey:      cfg.Auth.JWTSecret,
+		corsOrigins:    cfg.Server.CORSOrigins,
+		legacyBuyRoute: cfg.Shop.LegacyBuyRoute,
+		logger:         deps.Logger,
+
+		openAPIValidation: cfg.Server.OpenAPIValidation,
 
-		authService:        service.NewAuthService(db, jwtUtil),
+		authService:        service.NewAuthService(db, jwtUtil, deps.Clock, auditor),
 		infoService:        service.NewInfoService(db),
-		transactionService: service.NewTransactionService(db),
-		shopService:        service.NewShopService(db),
-	}
+		transactionService: service.NewTransactionService(db, auditor),
+		shopService:        service.NewShopService(db, auditor),
+		healthService:      service.NewHealthService(db),
+		webhookService:     service.NewWebhookService(db, notifier, auditor),
+		orderService:       service.NewOrderService(db, deps.Clock, cfg.Shop.RefundWindow, auditor),
+		adjustmentService:  service.NewAdjustmentService(db, auditor),
+		auditService:       service.NewAuditService(db),
+		statsService:       service.NewStatsService(db, cache, deps.Clock),
+		userService:        service.NewUserService(db, deps.Clock, auditor),
 
-	// Declare Server config
-	server := &http.Server{
-		Addr:         fmt.Sprintf(":%d", NewServer.port),
-		Handler:      NewServer.RegisterRoutes(),
-		IdleTimeout:  time.Minute,
-		ReadTimeout:  10 * time.Second,
-		WriteTimeout: 30 * time.Second,
+		scheduledTransferService: service.NewScheduledTransferService(db, deps.Clock),
 	}
 
-	return server
+	cleanup := func() {
+		for _, c := range cleanups {
+			c()
+		}
+	}
+	return NewServer.RegisterRoutes(), cleanup
 }
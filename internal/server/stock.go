@@ -0,0 +1,50 @@
+package server
+
+import (
+	"avitotech/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) RestockHandler(c *gin.Context) {
+	var req models.RestockRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.shopService.Restock(c.Request.Context(), adminId, c.Param("item"), req.Quantity)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) SetStockHandler(c *gin.Context) {
+	var req models.SetStockRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.shopService.SetStock(c.Request.Context(), adminId, c.Param("item"), req.Stock)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) SetPurchaseLimitHandler(c *gin.Context) {
+	var req models.SetPurchaseLimitRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.shopService.SetPurchaseLimit(c.Request.Context(), adminId, c.Param("item"), req.MaxPerUser)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
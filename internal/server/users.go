@@ -0,0 +1,94 @@
+package server
+
+import (
+	"avitotech/internal/models"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+func (s *Server) SearchUsersHandler(c *gin.Context) {
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.userService.Search(c.Query("q"), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) GetMeHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.userService.Me(userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) UpdateMeHandler(c *gin.Context) {
+	var req models.UpdateProfileRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.userService.UpdateProfile(userId, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) ExportMeHandler(c *gin.Context) {
+	userId, ok := currentUserID(c)
+	if !ok {
+		return
+	}
+	resp, err := s.userService.Export(c.Request.Context(), userId)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.Header("Content-Disposition", `attachment; filename="export.json"`)
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) DeactivateUserHandler(c *gin.Context) {
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.userService.Deactivate(c.Request.Context(), adminId, c.Param("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) ReactivateUserHandler(c *gin.Context) {
+	adminId, _ := c.Keys["userId"].(int)
+	resp, err := s.userService.Reactivate(c.Request.Context(), adminId, c.Param("username"))
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) EraseUserHandler(c *gin.Context) {
+	adminId, _ := c.Keys["userId"].(int)
+	if err := s.userService.Erase(c.Request.Context(), adminId, c.Param("username")); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
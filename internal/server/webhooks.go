@@ -0,0 +1,125 @@
+package server
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/models"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultListLimit = 50
+	maxListLimit     = 500
+)
+
+// pathID parses the integer ":id" path parameter, recording ErrInvalidRequest on failure.
+func pathID(c *gin.Context) (int64, bool) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil || id <= 0 {
+		abortWithError(c, fmt.Errorf("%w: id must be a positive integer", customErrors.ErrInvalidRequest))
+		return 0, false
+	}
+	return id, true
+}
+
+// queryLimit parses the "limit" query parameter, recording ErrInvalidRequest on failure.
+func queryLimit(c *gin.Context) (int, bool) {
+	raw := c.Query("limit")
+	if raw == "" {
+		return defaultListLimit, true
+	}
+	limit, err := strconv.Atoi(raw)
+	if err != nil || limit <= 0 || limit > maxListLimit {
+		abortWithError(c, fmt.Errorf("%w: limit must be 1 to %d", customErrors.ErrInvalidRequest, maxListLimit))
+		return 0, false
+	}
+	return limit, true
+}
+
+func (s *Server) CreateWebhookHandler(c *gin.Context) {
+	var req models.WebhookSubscriptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, _ := c.Keys["userId"].(int)
+	resp, err := s.webhookService.CreateSubscription(c.Request.Context(), userId, &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusCreated, resp)
+}
+
+func (s *Server) ListWebhooksHandler(c *gin.Context) {
+	resp, err := s.webhookService.ListSubscriptions()
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) UpdateWebhookHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	var req models.UpdateWebhookSubscriptionRequest
+	if !bindJSON(c, &req) {
+		return
+	}
+	userId, _ := c.Keys["userId"].(int)
+	resp, err := s.webhookService.UpdateSubscription(c.Request.Context(), userId, int(id), &req)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) DeleteWebhookHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	userId, _ := c.Keys["userId"].(int)
+	if err := s.webhookService.DeleteSubscription(c.Request.Context(), userId, int(id)); err != nil {
+		c.Error(err)
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+func (s *Server) ListWebhookDeliveriesHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	limit, ok := queryLimit(c)
+	if !ok {
+		return
+	}
+	resp, err := s.webhookService.ListDeliveries(int(id), limit)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
+
+func (s *Server) RedeliverWebhookHandler(c *gin.Context) {
+	id, ok := pathID(c)
+	if !ok {
+		return
+	}
+	userId, _ := c.Keys["userId"].(int)
+	resp, err := s.webhookService.Redeliver(c.Request.Context(), userId, id)
+	if err != nil {
+		c.Error(err)
+		return
+	}
+	c.JSON(http.StatusOK, resp)
+}
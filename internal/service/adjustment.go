@@ -0,0 +1,139 @@
+package service
+
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"context"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// maxAwardRows is the largest number of users awarded from one CSV upload.
+const maxAwardRows = 1000
+
+type AdjustmentService interface {
+	Mint(ctx context.Context, adminId int, username string, req *models.AdjustCoinsRequest) (*models.BalanceResponse, error)
+	Debit(ctx context.Context, adminId int, username string, req *models.AdjustCoinsRequest) (*models.BalanceResponse, error)
+	AwardCSV(ctx context.Context, adminId int, r io.Reader) (*models.AwardResponse, error)
+}
+
+type adjustmentService struct {
+	db    database.Service
+	audit audit.Recorder
+}
+
+func NewAdjustmentService(db database.Service, auditor audit.Recorder) *adjustmentService {
+	return &adjustmentService{
+		db:    db,
+		audit: auditor,
+	}
+}
+
+func (s *adjustmentService) Mint(ctx context.Context, adminId int, username string, req *models.AdjustCoinsRequest) (*models.BalanceResponse, error) {
+	if !entities.ValidAmount(req.Amount) {
+		return nil, customErrors.ErrInvalidAmount
+	}
+	user, err := s.db.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customErrors.ErrNotFound
+	}
+	coins, err := s.db.MintCoins(adminId, user.ID, req.Amount, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminMint, adminId, user.Username, audit.Details{"amount": req.Amount, "reason": req.Reason})
+	return &models.BalanceResponse{User: user.Username, Coins: coins}, nil
+}
+
+// Debit takes coins away from the user, a reason is required so the ledger explains the correction.
+func (s *adjustmentService) Debit(ctx context.Context, adminId int, username string, req *models.AdjustCoinsRequest) (*models.BalanceResponse, error) {
+	if !entities.ValidAmount(req.Amount) {
+		return nil, customErrors.ErrInvalidAmount
+	}
+	if strings.TrimSpace(req.Reason) == "" {
+		return nil, fmt.Errorf("%w: reason is required", customErrors.ErrInvalidData)
+	}
+	user, err := s.db.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customErrors.ErrNotFound
+	}
+	coins, err := s.db.DebitCoins(adminId, user.ID, req.Amount, req.Reason)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminDebit, adminId, user.Username, audit.Details{"amount": req.Amount, "reason": req.Reason})
+	return &models.BalanceResponse{User: user.Username, Coins: coins}, nil
+}
+
+// AwardCSV credits the users listed in a CSV of username,amount[,reason] rows, an optional header row is skipped.
+// Every row is validated before any coins are issued.
+func (s *adjustmentService) AwardCSV(ctx context.Context, adminId int, r io.Reader) (*models.AwardResponse, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	var awards []entities.Transfer
+	var recipients []string
+	total := 0
+	for line := 1; ; line++ {
+		record, err := reader.Read()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", customErrors.ErrInvalidData, err)
+		}
+		if line == 1 && strings.EqualFold(record[0], "username") {
+			continue
+		}
+		if len(record) < 2 || len(record) > 3 {
+			return nil, fmt.Errorf("%w: line %d: expected username,amount[,reason]", customErrors.ErrInvalidData, line)
+		}
+		amount, err := strconv.Atoi(record[1])
+		if err != nil || !entities.ValidAmount(amount) {
+			return nil, fmt.Errorf("%w: line %d: %v", customErrors.ErrInvalidData, line, customErrors.ErrInvalidAmount)
+		}
+		award := entities.Transfer{Amount: amount}
+		if len(record) == 3 {
+			award.Message = record[2]
+		}
+		if len(award.Message) > 255 {
+			return nil, fmt.Errorf("%w: line %d: reason is longer than 255 characters", customErrors.ErrInvalidData, line)
+		}
+		user, err := s.db.GetUserByName(record[0])
+		if err != nil {
+			return nil, err
+		}
+		if user == nil {
+			return nil, fmt.Errorf("%w: line %d: unknown user %q", customErrors.ErrInvalidData, line, record[0])
+		}
+		award.ToUserID = user.ID
+		awards = append(awards, award)
+		recipients = append(recipients, user.Username)
+		total += amount
+		if len(awards) > maxAwardRows {
+			return nil, fmt.Errorf("%w: at most %d rows are allowed", customErrors.ErrInvalidData, maxAwardRows)
+		}
+	}
+	if len(awards) == 0 {
+		return nil, fmt.Errorf("%w: no rows to award", customErrors.ErrInvalidData)
+	}
+	if err := s.db.AwardCoins(adminId, awards); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminAward, adminId, "", audit.Details{"recipients": recipients, "total": total})
+	return &models.AwardResponse{Awarded: len(awards), Total: total}, nil
+}
@@ -0,0 +1,109 @@
+package service
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"context"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+// AuditQuery is the raw filter of the audit log endpoint, empty fields match everything.
+type AuditQuery struct {
+	Actor  string
+	Action string
+	Target string
+	From   string
+	To     string
+	Before string
+}
+
+type AuditService interface {
+	ListEntries(ctx context.Context, query AuditQuery, limit int) ([]models.AuditEntryResponse, error)
+	Verify() (*models.AuditVerifyResponse, error)
+}
+
+type auditService struct {
+	db database.Service
+}
+
+func NewAuditService(db database.Service) *auditService {
+	return &auditService{
+		db: db,
+	}
+}
+
+func (s *auditService) ListEntries(ctx context.Context, query AuditQuery, limit int) ([]models.AuditEntryResponse, error) {
+	filter := entities.AuditFilter{Action: query.Action, Target: query.Target}
+	if query.Actor != "" {
+		actor, err := s.db.GetUserByName(query.Actor)
+		if err != nil {
+			return nil, err
+		}
+		if actor == nil {
+			return []models.AuditEntryResponse{}, nil
+		}
+		filter.ActorID = actor.ID
+	}
+	var err error
+	if filter.From, err = parseAuditTime("from", query.From); err != nil {
+		return nil, err
+	}
+	if filter.To, err = parseAuditTime("to", query.To); err != nil {
+		return nil, err
+	}
+	if query.Before != "" {
+		if filter.BeforeID, err = strconv.ParseInt(query.Before, 10, 64); err != nil || filter.BeforeID <= 0 {
+			return nil, fmt.Errorf("%w: before must be a positive entry id", customErrors.ErrInvalidData)
+		}
+	}
+
+	entries, err := s.db.GetAuditEntries(filter, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.AuditEntryResponse, 0, len(entries))
+	for _, entry := range entries {
+		item := models.AuditEntryResponse{
+			ID:        entry.ID,
+			Action:    entry.Action,
+			ActorID:   entry.ActorID,
+			Target:    entry.Target,
+			Details:   entry.Details,
+			IP:        entry.IP,
+			UserAgent: entry.UserAgent,
+			RequestID: entry.RequestID,
+			CreatedAt: entry.CreatedAt,
+			PrevHash:  entry.PrevHash,
+			Hash:      entry.Hash,
+		}
+		if entry.ActorID != 0 {
+			item.Actor = s.db.GetUserNameById(ctx, entry.ActorID)
+		}
+		resp = append(resp, item)
+	}
+	return resp, nil
+}
+
+// Verify recomputes the hash chain of the whole log.
+func (s *auditService) Verify() (*models.AuditVerifyResponse, error) {
+	checked, brokenAt, err := s.db.VerifyAuditLog()
+	if err != nil {
+		return nil, err
+	}
+	return &models.AuditVerifyResponse{Valid: brokenAt == 0, Checked: checked, BrokenAt: brokenAt}, nil
+}
+
+func parseAuditTime(name, value string) (time.Time, error) {
+	if value == "" {
+		return time.Time{}, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("%w: %s must be an RFC 3339 time", customErrors.ErrInvalidData, name)
+	}
+	return t, nil
+}
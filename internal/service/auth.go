@@ -1,30 +1,40 @@
 package service
 
 import (
+	"avitotech/internal/audit"
 	"avitotech/internal/customErrors"
 	"avitotech/internal/database"
 	"avitotech/internal/entities"
 	"avitotech/internal/models"
+	"avitotech/pkg/clock"
 	"avitotech/pkg/jwt"
+	"context"
 	"golang.org/x/crypto/bcrypt"
-	"time"
 )
 
 type AuthService interface {
-	Authenticate(req *models.AuthRequest) (*models.AuthResponse, error)
+	Authenticate(ctx context.Context, req *models.AuthRequest) (*models.AuthResponse, error)
+	IsAdmin(userId int) (bool, error)
+	// CheckActive fails with ErrUnauthorized when the user of a token no longer exists or is
+	// deactivated, tokens issued before a deactivation stop working right away.
+	CheckActive(userId int) error
 }
 type authService struct {
 	db      database.Service
 	jwtUtil *jwt.JWTUtil
+	clock   clock.Clock
+	audit   audit.Recorder
 }
 
-func NewAuthService(db database.Service, jwtUtil *jwt.JWTUtil) *authService {
+func NewAuthService(db database.Service, jwtUtil *jwt.JWTUtil, clk clock.Clock, auditor audit.Recorder) *authService {
 	return &authService{
 		db:      db,
 		jwtUtil: jwtUtil,
+		clock:   clk,
+		audit:   auditor,
 	}
 }
-func (s *authService) Authenticate(req *models.AuthRequest) (*models.AuthResponse, error) {
+func (s *authService) Authenticate(ctx context.Context, req *models.AuthRequest) (*models.AuthResponse, error) {
 	user, err := s.db.GetUserByName(req.Username)
 	if err != nil {
 		return nil, err
@@ -32,26 +42,36 @@ func (s *authService) Authenticate(req *models.AuthRequest) (*models.AuthRespons
 
 	// Если пользователь не существует, создаем нового
 	if user == nil {
+		if entities.IsReservedUsername(req.Username) {
+			return nil, customErrors.ErrInvalidUsername
+		}
 		hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 		if err != nil {
 			return nil, err
 		}
 
+		now := s.clock.Now()
 		user = &entities.User{
 			Username:  req.Username,
 			Password:  string(hashedPassword),
-			CreatedAt: time.Now(),
-			UpdatedAt: time.Now(),
+			CreatedAt: now,
+			UpdatedAt: now,
 		}
 
-		if err := s.db.AddUser(user); err != nil {
+		if err := s.db.AddUser(ctx, user); err != nil {
 			return nil, err
 		}
+		s.audit.Record(ctx, entities.AuditRegister, user.ID, user.Username, nil)
 	} else {
 		// Проверяем пароль существующего пользователя
 		if err := bcrypt.CompareHashAndPassword([]byte(user.Password), []byte(req.Password)); err != nil {
+			s.audit.Record(ctx, entities.AuditLoginFailed, 0, user.Username, nil)
 			return nil, customErrors.ErrInvalidCredentials
 		}
+		if user.Deactivated() {
+			s.audit.Record(ctx, entities.AuditLoginFailed, 0, user.Username, audit.Details{"reason": "deactivated"})
+			return nil, customErrors.ErrUserDeactivated
+		}
 	}
 
 	// Генерируем JWT токен
@@ -59,6 +79,26 @@ func (s *authService) Authenticate(req *models.AuthRequest) (*models.AuthRespons
 	if err != nil {
 		return nil, err
 	}
+	s.audit.Record(ctx, entities.AuditLogin, user.ID, user.Username, nil)
 
 	return &models.AuthResponse{Token: token}, nil
 }
+
+func (s *authService) IsAdmin(userId int) (bool, error) {
+	user, err := s.db.GetUserByID(userId)
+	if err != nil {
+		return false, err
+	}
+	return user != nil && user.IsAdmin, nil
+}
+
+func (s *authService) CheckActive(userId int) error {
+	active, err := s.db.IsUserActive(userId)
+	if err != nil {
+		return err
+	}
+	if !active {
+		return customErrors.ErrUnauthorized
+	}
+	return nil
+}
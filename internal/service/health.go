@@ -0,0 +1,56 @@
+package service
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/models"
+	"context"
+)
+
+const (
+	HealthStatusOK          = "ok"
+	HealthStatusUnavailable = "unavailable"
+)
+
+type HealthService interface {
+	// Check reports whether the service is available.
+	Check(ctx context.Context) *models.HealthResponse
+	// Details reports the availability together with the connection pool statistics.
+	Details(ctx context.Context) *models.HealthResponse
+}
+
+type healthService struct {
+	db database.Service
+}
+
+func NewHealthService(db database.Service) *healthService {
+	return &healthService{
+		db: db,
+	}
+}
+
+// Check pings the database.
+func (s *healthService) Check(ctx context.Context) *models.HealthResponse {
+	status := HealthStatusOK
+	if err := s.db.Ping(ctx); err != nil {
+		status = HealthStatusUnavailable
+	}
+	return &models.HealthResponse{Status: status}
+}
+
+// Details pings the database and reports the connection pool statistics.
+func (s *healthService) Details(ctx context.Context) *models.HealthResponse {
+	resp := s.Check(ctx)
+	stats := s.db.Stats()
+	resp.Database = &models.HealthDatabase{
+		MaxOpenConnections: stats.MaxOpenConnections,
+		OpenConnections:    stats.OpenConnections,
+		InUse:              stats.InUse,
+		Idle:               stats.Idle,
+		WaitCount:          stats.WaitCount,
+		WaitDurationMs:     stats.WaitDuration.Milliseconds(),
+		MaxIdleClosed:      stats.MaxIdleClosed,
+		MaxIdleTimeClosed:  stats.MaxIdleTimeClosed,
+		MaxLifetimeClosed:  stats.MaxLifetimeClosed,
+	}
+	return resp
+}
@@ -3,10 +3,11 @@ package service
 import (
 	"avitotech/internal/database"
 	"avitotech/internal/models"
+	"context"
 )
 
 type InfoService interface {
-	GetInfo(userId int) (*models.InfoResponse, error)
+	GetInfo(ctx context.Context, userId int) (*models.InfoResponse, error)
 }
 type infoService struct {
 	db database.Service
@@ -18,7 +19,7 @@ func NewInfoService(db database.Service) *infoService {
 	}
 }
 
-func (s *infoService) GetInfo(userId int) (*models.InfoResponse, error) {
+func (s *infoService) GetInfo(ctx context.Context, userId int) (*models.InfoResponse, error) {
 	response := models.NewInfoResponse()
 
 	// Получаем количество монет
@@ -45,17 +46,29 @@ func (s *infoService) GetInfo(userId int) (*models.InfoResponse, error) {
 	if err != nil {
 		return nil, err
 	}
+	// Refunds, mints, awards and debits have no counterparty, they are told apart by their type.
+	username := func(id int) string {
+		if id == 0 {
+			return ""
+		}
+		return s.db.GetUserNameById(ctx, id)
+	}
 	for _, transaction := range transactions {
 		if transaction.ToUserID == userId {
 			response.CoinHistory.Received = append(response.CoinHistory.Received, models.InfoResponseCoinHistoryReceived{
-				FromUser: s.db.GetUserNameById(transaction.FromUserID),
+				Type:     transaction.Type,
+				FromUser: username(transaction.FromUserID),
 				Amount:   transaction.Amount,
+				Message:  transaction.Message,
+				OrderID:  transaction.OrderID,
 			})
 		}
 		if transaction.FromUserID == userId {
 			response.CoinHistory.Sent = append(response.CoinHistory.Sent, models.InfoResponseCoinHistorySent{
-				ToUser: s.db.GetUserNameById(transaction.ToUserID),
-				Amount: transaction.Amount,
+				Type:    transaction.Type,
+				ToUser:  username(transaction.ToUserID),
+				Amount:  transaction.Amount,
+				Message: transaction.Message,
 			})
 		}
 	}
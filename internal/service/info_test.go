@@ -0,0 +1,64 @@
+package service
+
+import (
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"context"
+	"reflect"
+	"testing"
+)
+
+// fakeInfoDB serves the wallet of user 1, whose ledger holds every transaction type.
+type fakeInfoDB struct {
+	database.Service
+}
+
+func (fakeInfoDB) GetCoinsByUserID(int) (int, error) {
+	return 1000, nil
+}
+
+func (fakeInfoDB) GetInventoryByUserID(int) ([]entities.InventoryItem, error) {
+	return nil, nil
+}
+
+func (fakeInfoDB) GetTransactionsByUserID(int) ([]entities.Transaction, error) {
+	return []entities.Transaction{
+		{FromUserID: 2, ToUserID: 1, Amount: 20, Type: entities.TransactionSend, Message: "thanks"},
+		{FromUserID: 1, ToUserID: 2, Amount: 40, Type: entities.TransactionSend},
+		{ToUserID: 1, Amount: 500, Type: entities.TransactionMint, Message: "bonus", ActorID: 3},
+		{FromUserID: 1, Amount: 100, Type: entities.TransactionDebit, Message: "mistake", ActorID: 3},
+		{ToUserID: 1, Amount: 50, Type: entities.TransactionAward, Message: "hackathon", ActorID: 3},
+		{ToUserID: 1, Amount: 80, Type: entities.TransactionRefund, OrderID: 7},
+	}, nil
+}
+
+func (fakeInfoDB) GetUserNameById(_ context.Context, userId int) string {
+	if userId == 2 {
+		return "bob"
+	}
+	return "<unknown>"
+}
+
+func TestGetInfoHistoryTypes(t *testing.T) {
+	resp, err := NewInfoService(fakeInfoDB{}).GetInfo(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("expected GetInfo() to return nil, got %v", err)
+	}
+	wantReceived := []models.InfoResponseCoinHistoryReceived{
+		{Type: entities.TransactionSend, FromUser: "bob", Amount: 20, Message: "thanks"},
+		{Type: entities.TransactionMint, Amount: 500, Message: "bonus"},
+		{Type: entities.TransactionAward, Amount: 50, Message: "hackathon"},
+		{Type: entities.TransactionRefund, Amount: 80, OrderID: 7},
+	}
+	if !reflect.DeepEqual(resp.CoinHistory.Received, wantReceived) {
+		t.Fatalf("expected received history %+v, got %+v", wantReceived, resp.CoinHistory.Received)
+	}
+	wantSent := []models.InfoResponseCoinHistorySent{
+		{Type: entities.TransactionSend, ToUser: "bob", Amount: 40},
+		{Type: entities.TransactionDebit, Amount: 100, Message: "mistake"},
+	}
+	if !reflect.DeepEqual(resp.CoinHistory.Sent, wantSent) {
+		t.Fatalf("expected sent history %+v, got %+v", wantSent, resp.CoinHistory.Sent)
+	}
+}
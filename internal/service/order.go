@@ -0,0 +1,144 @@
+package service
+
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"avitotech/pkg/clock"
+	"context"
+	"fmt"
+	"time"
+)
+
+type OrderService interface {
+	ListUserOrders(userId int, status string, limit int) ([]models.OrderResponse, error)
+	GetUserOrder(userId, orderId int) (*models.OrderResponse, error)
+	ListOrders(status string, limit int) ([]models.OrderResponse, error)
+	UpdateStatus(ctx context.Context, adminId, orderId int, req *models.UpdateOrderStatusRequest) (*models.OrderResponse, error)
+	RefundUserOrder(ctx context.Context, userId, orderId int) (*models.OrderResponse, error)
+	RefundOrder(ctx context.Context, adminId, orderId int) (*models.OrderResponse, error)
+}
+
+type orderService struct {
+	db           database.Service
+	clock        clock.Clock
+	refundWindow time.Duration
+	audit        audit.Recorder
+}
+
+func NewOrderService(db database.Service, clk clock.Clock, refundWindow time.Duration, auditor audit.Recorder) *orderService {
+	return &orderService{
+		db:           db,
+		clock:        clk,
+		refundWindow: refundWindow,
+		audit:        auditor,
+	}
+}
+
+func (s *orderService) ListUserOrders(userId int, status string, limit int) ([]models.OrderResponse, error) {
+	orderStatus, err := parseOrderStatusFilter(status)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.db.GetOrdersByUserID(userId, orderStatus, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newOrderResponses(orders), nil
+}
+
+// GetUserOrder returns the order only if it belongs to the user, so other users' orders look missing.
+func (s *orderService) GetUserOrder(userId, orderId int) (*models.OrderResponse, error) {
+	order, err := s.db.GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userId {
+		return nil, customErrors.ErrNotFound
+	}
+	return newOrderResponse(order), nil
+}
+
+func (s *orderService) ListOrders(status string, limit int) ([]models.OrderResponse, error) {
+	orderStatus, err := parseOrderStatusFilter(status)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := s.db.GetOrders(orderStatus, limit)
+	if err != nil {
+		return nil, err
+	}
+	return newOrderResponses(orders), nil
+}
+
+func (s *orderService) UpdateStatus(ctx context.Context, adminId, orderId int, req *models.UpdateOrderStatusRequest) (*models.OrderResponse, error) {
+	order, err := s.db.UpdateOrderStatus(orderId, entities.OrderStatus(req.Status))
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminOrderStatus, adminId, orderTarget(orderId), audit.Details{"status": order.Status})
+	return newOrderResponse(order), nil
+}
+
+// RefundUserOrder refunds the user's own order if it was placed within the refund window.
+func (s *orderService) RefundUserOrder(ctx context.Context, userId, orderId int) (*models.OrderResponse, error) {
+	order, err := s.db.GetOrder(orderId)
+	if err != nil {
+		return nil, err
+	}
+	if order.UserID != userId {
+		return nil, customErrors.ErrNotFound
+	}
+	if order, err = s.db.RefundOrder(orderId, s.clock.Now().Add(-s.refundWindow)); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditRefund, userId, orderTarget(orderId), audit.Details{"amount": order.Price * order.Quantity})
+	return newOrderResponse(order), nil
+}
+
+// RefundOrder refunds any order that has not been delivered yet, regardless of the refund window.
+func (s *orderService) RefundOrder(ctx context.Context, adminId, orderId int) (*models.OrderResponse, error) {
+	order, err := s.db.RefundOrder(orderId, time.Time{})
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminRefund, adminId, orderTarget(orderId), audit.Details{"amount": order.Price * order.Quantity})
+	return newOrderResponse(order), nil
+}
+
+// orderTarget names the order in the audit log.
+func orderTarget(orderId int) string {
+	return fmt.Sprintf("order:%d", orderId)
+}
+
+// parseOrderStatusFilter validates an optional status filter; empty means any status.
+func parseOrderStatusFilter(status string) (entities.OrderStatus, error) {
+	orderStatus := entities.OrderStatus(status)
+	if status != "" && !orderStatus.Valid() {
+		return "", fmt.Errorf("%w: unknown order status %q", customErrors.ErrInvalidData, status)
+	}
+	return orderStatus, nil
+}
+
+func newOrderResponses(orders []entities.Order) []models.OrderResponse {
+	resp := make([]models.OrderResponse, 0, len(orders))
+	for _, order := range orders {
+		resp = append(resp, *newOrderResponse(&order))
+	}
+	return resp
+}
+
+func newOrderResponse(order *entities.Order) *models.OrderResponse {
+	return &models.OrderResponse{
+		ID:        order.ID,
+		UserID:    order.UserID,
+		ItemType:  order.ItemType,
+		Quantity:  order.Quantity,
+		Price:     order.Price,
+		Status:    string(order.Status),
+		CreatedAt: order.CreatedAt,
+		UpdatedAt: order.UpdatedAt,
+	}
+}
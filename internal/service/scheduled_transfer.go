@@ -0,0 +1,133 @@
+package service
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"avitotech/internal/scheduler"
+	"avitotech/pkg/clock"
+	"context"
+	"fmt"
+)
+
+type ScheduledTransferService interface {
+	Create(userId int, req *models.ScheduledTransferRequest) (*models.ScheduledTransferResponse, error)
+	List(ctx context.Context, userId int) ([]models.ScheduledTransferResponse, error)
+	Cancel(userId, transferId int) error
+	ListRuns(userId, transferId, limit int) ([]models.ScheduledTransferRunResponse, error)
+}
+
+type scheduledTransferService struct {
+	db    database.Service
+	clock clock.Clock
+}
+
+func NewScheduledTransferService(db database.Service, clk clock.Clock) *scheduledTransferService {
+	return &scheduledTransferService{
+		db:    db,
+		clock: clk,
+	}
+}
+
+func (s *scheduledTransferService) Create(userId int, req *models.ScheduledTransferRequest) (*models.ScheduledTransferResponse, error) {
+	if !entities.ValidAmount(req.Amount) {
+		return nil, customErrors.ErrInvalidAmount
+	}
+	toUser, err := s.db.GetUserByName(req.ToUser)
+	if err != nil || toUser == nil || toUser.ID == userId {
+		return nil, customErrors.ErrInvalidUsername
+	}
+	if toUser.Deactivated() {
+		return nil, customErrors.ErrUserDeactivated
+	}
+	// The daily limit and the balance cap are checked when the transfer runs.
+	if err := s.db.CheckTransferAmount(req.Amount); err != nil {
+		return nil, err
+	}
+	transfer := &entities.ScheduledTransfer{
+		FromUserID: userId,
+		ToUserID:   toUser.ID,
+		Amount:     req.Amount,
+		Message:    req.Message,
+		RunAt:      req.RunAt,
+		Cron:       req.Cron,
+	}
+	now := s.clock.Now()
+	switch {
+	case (req.RunAt == nil) == (req.Cron == ""):
+		return nil, fmt.Errorf("%w: exactly one of runAt and cron must be set", customErrors.ErrInvalidData)
+	case req.RunAt != nil:
+		if !req.RunAt.After(now) {
+			return nil, fmt.Errorf("%w: runAt must be in the future", customErrors.ErrInvalidData)
+		}
+		transfer.NextRunAt = req.RunAt
+	default:
+		next, err := scheduler.NextRun(req.Cron, now.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", customErrors.ErrInvalidData, err)
+		}
+		transfer.NextRunAt = &next
+	}
+	if err := s.db.CreateScheduledTransfer(transfer); err != nil {
+		return nil, err
+	}
+	return newScheduledTransferResponse(transfer, toUser.Username), nil
+}
+
+func (s *scheduledTransferService) List(ctx context.Context, userId int) ([]models.ScheduledTransferResponse, error) {
+	transfers, err := s.db.GetScheduledTransfersByUserID(userId)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.ScheduledTransferResponse, 0, len(transfers))
+	for _, transfer := range transfers {
+		resp = append(resp, *newScheduledTransferResponse(&transfer, s.db.GetUserNameById(ctx, transfer.ToUserID)))
+	}
+	return resp, nil
+}
+
+func (s *scheduledTransferService) Cancel(userId, transferId int) error {
+	return s.db.CancelScheduledTransfer(transferId, userId)
+}
+
+// ListRuns returns the execution history of a transfer created by the user.
+func (s *scheduledTransferService) ListRuns(userId, transferId, limit int) ([]models.ScheduledTransferRunResponse, error) {
+	transfer, err := s.db.GetScheduledTransfer(transferId)
+	if err != nil {
+		return nil, err
+	}
+	if transfer.FromUserID != userId {
+		return nil, customErrors.ErrNotFound
+	}
+	runs, err := s.db.GetScheduledTransferRuns(transferId, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.ScheduledTransferRunResponse, 0, len(runs))
+	for _, run := range runs {
+		resp = append(resp, models.ScheduledTransferRunResponse{
+			ID:           run.ID,
+			ScheduledFor: run.ScheduledFor,
+			ExecutedAt:   run.ExecutedAt,
+			Success:      run.Success,
+			Error:        run.Error,
+		})
+	}
+	return resp, nil
+}
+
+func newScheduledTransferResponse(transfer *entities.ScheduledTransfer, toUser string) *models.ScheduledTransferResponse {
+	return &models.ScheduledTransferResponse{
+		ID:                  transfer.ID,
+		ToUser:              toUser,
+		Amount:              transfer.Amount,
+		Message:             transfer.Message,
+		RunAt:               transfer.RunAt,
+		Cron:                transfer.Cron,
+		NextRunAt:           transfer.NextRunAt,
+		Active:              transfer.Active,
+		ConsecutiveFailures: transfer.ConsecutiveFailures,
+		CreatedAt:           transfer.CreatedAt,
+	}
+}
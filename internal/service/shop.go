@@ -1,25 +1,106 @@
 package service
 
-import "avitotech/internal/database"
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"context"
+)
 
 type ShopService interface {
-	BuyItem(userId int, itemType string) error
+	ListItems() (*models.ShopResponse, error)
+	BuyItem(ctx context.Context, userId int, itemType string, quantity int) (*models.OrderResponse, error)
+	Checkout(ctx context.Context, userId int, req *models.CheckoutRequest) (*models.CheckoutResponse, error)
+	Restock(ctx context.Context, adminId int, itemType string, quantity int) (*models.ShopResponseItem, error)
+	SetStock(ctx context.Context, adminId int, itemType string, stock *int) (*models.ShopResponseItem, error)
+	SetPurchaseLimit(ctx context.Context, adminId int, itemType string, maxPerUser *int) (*models.ShopResponseItem, error)
 }
 
 type shopService struct {
-	db database.Service
+	db    database.Service
+	audit audit.Recorder
 }
 
-func NewShopService(db database.Service) *shopService {
+func NewShopService(db database.Service, auditor audit.Recorder) *shopService {
 	return &shopService{
-		db: db,
+		db:    db,
+		audit: auditor,
 	}
 }
 
-func (s *shopService) BuyItem(userId int, itemType string) error {
-	err := s.db.BuyItem(userId, itemType)
+func (s *shopService) ListItems() (*models.ShopResponse, error) {
+	items, err := s.db.GetShopItems()
 	if err != nil {
-		return err
+		return nil, err
+	}
+	response := &models.ShopResponse{Items: make([]models.ShopResponseItem, 0, len(items))}
+	for _, item := range items {
+		response.Items = append(response.Items, *newShopResponseItem(&item))
+	}
+	return response, nil
+}
+
+func (s *shopService) BuyItem(ctx context.Context, userId int, itemType string, quantity int) (*models.OrderResponse, error) {
+	order, err := s.db.BuyItem(userId, itemType, quantity)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditPurchase, userId, itemType, audit.Details{"orderId": order.ID, "quantity": quantity, "price": order.Price})
+	return newOrderResponse(order), nil
+}
+
+func (s *shopService) Checkout(ctx context.Context, userId int, req *models.CheckoutRequest) (*models.CheckoutResponse, error) {
+	lines := make([]entities.CartLine, 0, len(req.Items))
+	for _, line := range req.Items {
+		lines = append(lines, entities.CartLine{ItemType: line.Item, Quantity: line.Quantity})
+	}
+	orders, err := s.db.Checkout(userId, lines)
+	if err != nil {
+		return nil, err
+	}
+	response := &models.CheckoutResponse{Orders: newOrderResponses(orders)}
+	orderIds := make([]int, 0, len(orders))
+	for _, order := range orders {
+		response.Total += order.Price * order.Quantity
+		orderIds = append(orderIds, order.ID)
+	}
+	s.audit.Record(ctx, entities.AuditCheckout, userId, "", audit.Details{"orderIds": orderIds, "total": response.Total})
+	return response, nil
+}
+
+func (s *shopService) Restock(ctx context.Context, adminId int, itemType string, quantity int) (*models.ShopResponseItem, error) {
+	item, err := s.db.RestockItem(itemType, quantity)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminRestock, adminId, itemType, audit.Details{"quantity": quantity, "stock": item.Stock})
+	return newShopResponseItem(item), nil
+}
+
+func (s *shopService) SetStock(ctx context.Context, adminId int, itemType string, stock *int) (*models.ShopResponseItem, error) {
+	item, err := s.db.SetItemStock(itemType, stock)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminStock, adminId, itemType, audit.Details{"stock": stock})
+	return newShopResponseItem(item), nil
+}
+
+func (s *shopService) SetPurchaseLimit(ctx context.Context, adminId int, itemType string, maxPerUser *int) (*models.ShopResponseItem, error) {
+	item, err := s.db.SetItemPurchaseLimit(itemType, maxPerUser)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminLimit, adminId, itemType, audit.Details{"maxPerUser": maxPerUser})
+	return newShopResponseItem(item), nil
+}
+
+func newShopResponseItem(item *entities.ShopItem) *models.ShopResponseItem {
+	return &models.ShopResponseItem{
+		Type:       item.ItemType,
+		Price:      item.Price,
+		Stock:      item.Stock,
+		MaxPerUser: item.MaxPerUser,
 	}
-	return nil
 }
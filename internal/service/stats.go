@@ -0,0 +1,129 @@
+package service
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"avitotech/pkg/clock"
+	"avitotech/pkg/imcache"
+	"fmt"
+	"strconv"
+	"time"
+)
+
+const (
+	StatsWindowDay   = "day"
+	StatsWindowWeek  = "week"
+	StatsWindowMonth = "month"
+	StatsWindowAll   = "all"
+)
+
+// statsWindows are the lengths of the rolling leaderboard windows, zero means all time.
+var statsWindows = map[string]time.Duration{
+	StatsWindowDay:   24 * time.Hour,
+	StatsWindowWeek:  7 * 24 * time.Hour,
+	StatsWindowMonth: 30 * 24 * time.Hour,
+	StatsWindowAll:   0,
+}
+
+type StatsService interface {
+	// Leaderboard ranks the top senders, receivers and spenders over the window.
+	// An empty window means a week.
+	Leaderboard(window string, limit int) (*models.LeaderboardResponse, error)
+	// Me computes the all-time totals of the user's wallet.
+	Me(userId int) (*models.MyStatsResponse, error)
+}
+
+// statsService caches its results, so they may lag behind by up to the cache TTL.
+type statsService struct {
+	db    database.Service
+	cache imcache.Cache
+	clock clock.Clock
+}
+
+func NewStatsService(db database.Service, cache imcache.Cache, clk clock.Clock) *statsService {
+	return &statsService{
+		db:    db,
+		cache: cache,
+		clock: clk,
+	}
+}
+
+func (s *statsService) Leaderboard(window string, limit int) (*models.LeaderboardResponse, error) {
+	if window == "" {
+		window = StatsWindowWeek
+	}
+	length, ok := statsWindows[window]
+	if !ok {
+		return nil, fmt.Errorf("%w: window must be one of day, week, month, all", customErrors.ErrInvalidData)
+	}
+	key := "stats:leaderboard:" + window + ":" + strconv.Itoa(limit)
+	if cached, ok := s.cache.Get(key); ok {
+		if resp, ok := cached.(*models.LeaderboardResponse); ok {
+			return resp, nil
+		}
+	}
+
+	resp := &models.LeaderboardResponse{Window: window}
+	var since time.Time
+	if length > 0 {
+		since = s.clock.Now().Add(-length)
+		resp.Since = &since
+	}
+	senders, err := s.db.GetTopSenders(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	receivers, err := s.db.GetTopReceivers(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	spenders, err := s.db.GetTopSpenders(since, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp.Senders = rankEntries(senders)
+	resp.Receivers = rankEntries(receivers)
+	resp.Spenders = rankEntries(spenders)
+
+	s.cache.Set(key, resp)
+	return resp, nil
+}
+
+func rankEntries(entries []entities.LeaderboardEntry) []models.LeaderboardEntry {
+	ranked := make([]models.LeaderboardEntry, 0, len(entries))
+	for i, entry := range entries {
+		ranked = append(ranked, models.LeaderboardEntry{
+			Rank:     i + 1,
+			Username: entry.Username,
+			Total:    entry.Total,
+			Count:    entry.Count,
+		})
+	}
+	return ranked
+}
+
+func (s *statsService) Me(userId int) (*models.MyStatsResponse, error) {
+	key := "stats:me:" + strconv.Itoa(userId)
+	if cached, ok := s.cache.Get(key); ok {
+		if resp, ok := cached.(*models.MyStatsResponse); ok {
+			return resp, nil
+		}
+	}
+
+	stats, err := s.db.GetWalletStats(userId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.MyStatsResponse{
+		Sent:           stats.Sent,
+		Received:       stats.Received,
+		Spent:          stats.Spent,
+		Counterparties: stats.Counterparties,
+		FavouriteItem:  stats.FavouriteItem,
+	}
+
+	s.cache.Set(key, resp)
+	return resp, nil
+}
@@ -1,26 +1,39 @@
 package service
 
 import (
+	"avitotech/internal/audit"
 	"avitotech/internal/customErrors"
 	"avitotech/internal/database"
+	"avitotech/internal/entities"
 	"avitotech/internal/models"
+	"context"
+	"fmt"
 )
 
 type TransactionService interface {
-	SendCoin(userID int, req *models.SendCoinRequest) error
+	SendCoin(ctx context.Context, userID int, req *models.SendCoinRequest) error
+	SendCoinBulk(ctx context.Context, userID int, req *models.BulkSendCoinRequest) (*models.BulkSendCoinResponse, error)
+	RequestCoins(ctx context.Context, userID int, req *models.RequestCoinsRequest) (*models.CoinRequestResponse, error)
+	ListCoinRequests(ctx context.Context, userID int, status string, limit int) ([]models.CoinRequestResponse, error)
+	ResolveCoinRequest(ctx context.Context, userID, requestID int, approve bool) (*models.CoinRequestResponse, error)
 }
 
 type transactionService struct {
-	db database.Service
+	db    database.Service
+	audit audit.Recorder
 }
 
-func NewTransactionService(db database.Service) *transactionService {
+func NewTransactionService(db database.Service, auditor audit.Recorder) *transactionService {
 	return &transactionService{
-		db: db,
+		db:    db,
+		audit: auditor,
 	}
 }
 
-func (s *transactionService) SendCoin(userID int, req *models.SendCoinRequest) error {
+func (s *transactionService) SendCoin(ctx context.Context, userID int, req *models.SendCoinRequest) error {
+	if !entities.ValidAmount(req.Amount) {
+		return customErrors.ErrInvalidAmount
+	}
 	toUser, err := s.db.GetUserByName(req.ToUser)
 	if err != nil || toUser == nil {
 		return customErrors.ErrInvalidUsername
@@ -28,9 +41,154 @@ func (s *transactionService) SendCoin(userID int, req *models.SendCoinRequest) e
 	if toUser.ID == userID {
 		return customErrors.ErrInvalidUsername
 	}
-	err = s.db.SendCoin(userID, toUser.ID, req.Amount)
+	if toUser.Deactivated() {
+		return customErrors.ErrUserDeactivated
+	}
+	err = s.db.SendCoin(userID, toUser.ID, req.Amount, req.Message)
 	if err != nil {
 		return err
 	}
+	s.audit.Record(ctx, entities.AuditTransfer, userID, toUser.Username, audit.Details{"amount": req.Amount})
 	return nil
 }
+
+// SendCoinBulk validates every recipient before sending anything and then sends all transfers atomically.
+// The error of a rejected batch carries the transfers explaining each recipient as details.
+func (s *transactionService) SendCoinBulk(ctx context.Context, userID int, req *models.BulkSendCoinRequest) (*models.BulkSendCoinResponse, error) {
+	resp := &models.BulkSendCoinResponse{Transfers: make([]models.BulkTransferResult, 0, len(req.Transfers))}
+	transfers := make([]entities.Transfer, 0, len(req.Transfers))
+	seen := make(map[string]bool, len(req.Transfers))
+	rejected := false
+	total := 0
+	for _, t := range req.Transfers {
+		result := models.BulkTransferResult{ToUser: t.ToUser, Amount: t.Amount, Status: models.BulkTransferSent}
+		toUser, err := s.db.GetUserByName(t.ToUser)
+		amountErr := s.db.CheckTransferAmount(t.Amount)
+		switch {
+		case err != nil:
+			return nil, err
+		case toUser == nil, toUser.ID == userID:
+			result.Error = customErrors.ErrInvalidUsername.Error()
+		case toUser.Deactivated():
+			result.Error = customErrors.ErrUserDeactivated.Error()
+		case seen[t.ToUser]:
+			result.Error = "duplicate recipient"
+		case !entities.ValidAmount(t.Amount):
+			result.Error = customErrors.ErrInvalidAmount.Error()
+		case amountErr != nil:
+			result.Error = amountErr.Error()
+		default:
+			transfers = append(transfers, entities.Transfer{ToUserID: toUser.ID, Amount: t.Amount, Message: t.Message})
+			total += t.Amount
+		}
+		seen[t.ToUser] = true
+		if result.Error != "" {
+			result.Status = models.BulkTransferRejected
+			rejected = true
+		}
+		resp.Transfers = append(resp.Transfers, result)
+	}
+	if rejected {
+		return s.rejectBulk(resp, customErrors.ErrInvalidData)
+	}
+
+	coins, err := s.db.GetCoinsByUserID(userID)
+	if err != nil {
+		return nil, err
+	}
+	if coins < total {
+		return s.rejectBulk(resp, customErrors.ErrNotEnoughCoins)
+	}
+	if err := s.db.SendCoinBulk(userID, transfers); err != nil {
+		if _, domain := customErrors.As(err); domain {
+			// The balance changed since the check, or a transfer breaks the wallet rules.
+			return s.rejectBulk(resp, err)
+		}
+		return nil, err
+	}
+	recipients := make([]string, 0, len(resp.Transfers))
+	for _, t := range resp.Transfers {
+		recipients = append(recipients, t.ToUser)
+	}
+	s.audit.Record(ctx, entities.AuditBulkTransfer, userID, "", audit.Details{"recipients": recipients, "total": total})
+	return resp, nil
+}
+
+// rejectBulk marks every transfer of the batch as not sent and attaches them to the error.
+func (s *transactionService) rejectBulk(resp *models.BulkSendCoinResponse, err error) (*models.BulkSendCoinResponse, error) {
+	for i := range resp.Transfers {
+		resp.Transfers[i].Status = models.BulkTransferRejected
+	}
+	return nil, customErrors.WithDetails(err, map[string]any{"transfers": resp.Transfers})
+}
+
+func (s *transactionService) RequestCoins(ctx context.Context, userID int, req *models.RequestCoinsRequest) (*models.CoinRequestResponse, error) {
+	if !entities.ValidAmount(req.Amount) {
+		return nil, customErrors.ErrInvalidAmount
+	}
+	payer, err := s.db.GetUserByName(req.FromUser)
+	if err != nil || payer == nil {
+		return nil, customErrors.ErrInvalidUsername
+	}
+	if payer.ID == userID {
+		return nil, customErrors.ErrInvalidUsername
+	}
+	if payer.Deactivated() {
+		return nil, customErrors.ErrUserDeactivated
+	}
+	if err := s.db.CheckTransferAmount(req.Amount); err != nil {
+		return nil, err
+	}
+	request := &entities.CoinRequest{
+		RequesterID: userID,
+		PayerID:     payer.ID,
+		Amount:      req.Amount,
+		Message:     req.Message,
+	}
+	if err := s.db.CreateCoinRequest(request); err != nil {
+		return nil, err
+	}
+	return s.newCoinRequestResponse(ctx, request), nil
+}
+
+func (s *transactionService) ListCoinRequests(ctx context.Context, userID int, status string, limit int) ([]models.CoinRequestResponse, error) {
+	requestStatus := entities.CoinRequestStatus(status)
+	if status != "" && !requestStatus.Valid() {
+		return nil, fmt.Errorf("%w: unknown coin request status %q", customErrors.ErrInvalidData, status)
+	}
+	requests, err := s.db.GetCoinRequestsByUserID(userID, requestStatus, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.CoinRequestResponse, 0, len(requests))
+	for _, request := range requests {
+		resp = append(resp, *s.newCoinRequestResponse(ctx, &request))
+	}
+	return resp, nil
+}
+
+// ResolveCoinRequest approves or declines a request addressed to the user, approving pays it.
+func (s *transactionService) ResolveCoinRequest(ctx context.Context, userID, requestID int, approve bool) (*models.CoinRequestResponse, error) {
+	request, err := s.db.ResolveCoinRequest(requestID, userID, approve)
+	if err != nil {
+		return nil, err
+	}
+	resp := s.newCoinRequestResponse(ctx, request)
+	if approve {
+		s.audit.Record(ctx, entities.AuditCoinRequestPaid, userID, resp.ToUser, audit.Details{"requestId": request.ID, "amount": request.Amount})
+	}
+	return resp, nil
+}
+
+func (s *transactionService) newCoinRequestResponse(ctx context.Context, request *entities.CoinRequest) *models.CoinRequestResponse {
+	return &models.CoinRequestResponse{
+		ID:        request.ID,
+		FromUser:  s.db.GetUserNameById(ctx, request.PayerID),
+		ToUser:    s.db.GetUserNameById(ctx, request.RequesterID),
+		Amount:    request.Amount,
+		Message:   request.Message,
+		Status:    string(request.Status),
+		CreatedAt: request.CreatedAt,
+		UpdatedAt: request.UpdatedAt,
+	}
+}
@@ -0,0 +1,64 @@
+package service
+
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"context"
+	"errors"
+	"math"
+	"testing"
+	"testing/quick"
+)
+
+// fakeTransferDB knows two users and records the transfers that reach it.
+type fakeTransferDB struct {
+	database.Service
+
+	sent []int
+}
+
+func (f *fakeTransferDB) GetUserByName(username string) (*entities.User, error) {
+	switch username {
+	case "alice":
+		return &entities.User{ID: 1, Username: username}, nil
+	case "bob":
+		return &entities.User{ID: 2, Username: username}, nil
+	}
+	return nil, nil
+}
+
+func (f *fakeTransferDB) SendCoin(fromUserID, toUserID, amount int, message string) error {
+	f.sent = append(f.sent, amount)
+	return nil
+}
+
+type nopRecorder struct{}
+
+func (nopRecorder) Record(context.Context, string, int, string, audit.Details) {}
+
+func TestSendCoinAmountProperty(t *testing.T) {
+	db := &fakeTransferDB{}
+	svc := NewTransactionService(db, nopRecorder{})
+
+	// A transfer reaches the store exactly when its amount is valid, anything else is rejected up front.
+	property := func(amount int) bool {
+		before := len(db.sent)
+		err := svc.SendCoin(context.Background(), 1, &models.SendCoinRequest{ToUser: "bob", Amount: amount})
+		reached := len(db.sent) > before
+		if entities.ValidAmount(amount) {
+			return err == nil && reached && db.sent[len(db.sent)-1] == amount
+		}
+		return errors.Is(err, customErrors.ErrInvalidAmount) && !reached
+	}
+	if err := quick.Check(property, &quick.Config{MaxCount: 5000}); err != nil {
+		t.Error(err)
+	}
+	for _, amount := range []int{math.MinInt, -500, -1, 0, 1, entities.MaxAmount, entities.MaxAmount + 1, math.MaxInt32, math.MaxInt} {
+		if !property(amount) {
+			t.Errorf("SendCoin() with amount %d broke the property", amount)
+		}
+	}
+}
@@ -0,0 +1,222 @@
+package service
+
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/models"
+	"avitotech/pkg/clock"
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"unicode/utf8"
+)
+
+// maxSearchQuery is the longest directory search query, in characters.
+const maxSearchQuery = 64
+
+type UserService interface {
+	// Search looks up users by username prefix or similarity.
+	Search(query string, limit int) ([]models.UserSearchResult, error)
+	// Me returns the profile of the user.
+	Me(userId int) (*models.UserProfileResponse, error)
+	// UpdateProfile sets the display name and department of the user.
+	UpdateProfile(userId int, req *models.UpdateProfileRequest) (*models.UserProfileResponse, error)
+	// Export collects the personal data of the user: profile, balance, inventory and history.
+	Export(ctx context.Context, userId int) (*models.DataExportResponse, error)
+	// Deactivate blocks the login and incoming transfers of the user.
+	Deactivate(ctx context.Context, adminId int, username string) (*models.UserProfileResponse, error)
+	// Reactivate lifts the deactivation of the user, erased users cannot be reactivated.
+	Reactivate(ctx context.Context, adminId int, username string) (*models.UserProfileResponse, error)
+	// Erase anonymizes the user and erases their personal data, keeping the ledger intact.
+	Erase(ctx context.Context, adminId int, username string) error
+}
+
+type userService struct {
+	db    database.Service
+	clock clock.Clock
+	audit audit.Recorder
+}
+
+func NewUserService(db database.Service, clk clock.Clock, auditor audit.Recorder) *userService {
+	return &userService{
+		db:    db,
+		clock: clk,
+		audit: auditor,
+	}
+}
+
+func (s *userService) Search(query string, limit int) ([]models.UserSearchResult, error) {
+	query = strings.TrimSpace(query)
+	if query == "" || utf8.RuneCountInString(query) > maxSearchQuery {
+		return nil, fmt.Errorf("%w: q must be 1 to %d characters", customErrors.ErrInvalidData, maxSearchQuery)
+	}
+	users, err := s.db.SearchUsers(query, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.UserSearchResult, 0, len(users))
+	for _, user := range users {
+		resp = append(resp, models.UserSearchResult{
+			Username:    user.Username,
+			DisplayName: user.DisplayName,
+			Department:  user.Department,
+		})
+	}
+	return resp, nil
+}
+
+func (s *userService) Me(userId int) (*models.UserProfileResponse, error) {
+	user, err := s.db.GetUserByID(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customErrors.ErrNotFound
+	}
+	return newUserProfileResponse(user), nil
+}
+
+func (s *userService) UpdateProfile(userId int, req *models.UpdateProfileRequest) (*models.UserProfileResponse, error) {
+	user, err := s.db.GetUserByID(userId)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customErrors.ErrNotFound
+	}
+	displayName, department := user.DisplayName, user.Department
+	if req.DisplayName != nil {
+		displayName = strings.TrimSpace(*req.DisplayName)
+	}
+	if req.Department != nil {
+		department = strings.TrimSpace(*req.Department)
+	}
+	if user, err = s.db.UpdateUserProfile(userId, displayName, department); err != nil {
+		return nil, err
+	}
+	return newUserProfileResponse(user), nil
+}
+
+func (s *userService) Export(ctx context.Context, userId int) (*models.DataExportResponse, error) {
+	profile, err := s.Me(userId)
+	if err != nil {
+		return nil, err
+	}
+	resp := &models.DataExportResponse{
+		ExportedAt:   s.clock.Now(),
+		Profile:      *profile,
+		Inventory:    []models.InfoResponseInventory{},
+		Transactions: []models.ExportTransaction{},
+	}
+	if resp.Coins, err = s.db.GetCoinsByUserID(userId); err != nil {
+		return nil, err
+	}
+	items, err := s.db.GetInventoryByUserID(userId)
+	if err != nil {
+		return nil, err
+	}
+	for _, item := range items {
+		resp.Inventory = append(resp.Inventory, models.InfoResponseInventory{Type: item.ItemType, Quantity: item.Quantity})
+	}
+
+	transactions, err := s.db.GetTransactionsByUserID(userId)
+	if err != nil {
+		return nil, err
+	}
+	usernames := map[int]string{0: ""}
+	username := func(id int) string {
+		if name, ok := usernames[id]; ok {
+			return name
+		}
+		usernames[id] = s.db.GetUserNameById(ctx, id)
+		return usernames[id]
+	}
+	for _, transaction := range transactions {
+		resp.Transactions = append(resp.Transactions, models.ExportTransaction{
+			Type:      transaction.Type,
+			FromUser:  username(transaction.FromUserID),
+			ToUser:    username(transaction.ToUserID),
+			Amount:    transaction.Amount,
+			Message:   transaction.Message,
+			OrderID:   transaction.OrderID,
+			CreatedAt: transaction.CreatedAt,
+		})
+	}
+
+	orders, err := s.db.GetOrdersByUserID(userId, "", math.MaxInt32)
+	if err != nil {
+		return nil, err
+	}
+	resp.Orders = newOrderResponses(orders)
+
+	s.audit.Record(ctx, entities.AuditDataExport, userId, profile.Username, nil)
+	return resp, nil
+}
+
+func (s *userService) Deactivate(ctx context.Context, adminId int, username string) (*models.UserProfileResponse, error) {
+	user, err := s.userByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.db.DeactivateUser(user.ID); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminDeactivate, adminId, user.Username, nil)
+	return s.Me(user.ID)
+}
+
+func (s *userService) Reactivate(ctx context.Context, adminId int, username string) (*models.UserProfileResponse, error) {
+	user, err := s.userByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if user.ErasedAt != nil {
+		return nil, fmt.Errorf("%w: erased users cannot be reactivated", customErrors.ErrInvalidData)
+	}
+	if err := s.db.ReactivateUser(user.ID); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminReactivate, adminId, user.Username, nil)
+	return s.Me(user.ID)
+}
+
+// Erase is idempotent. Its audit entry targets the user by ID, so the append-only log does not repeat the erased username.
+func (s *userService) Erase(ctx context.Context, adminId int, username string) error {
+	user, err := s.userByName(username)
+	if err != nil {
+		return err
+	}
+	if user.ErasedAt != nil {
+		return nil
+	}
+	if err := s.db.EraseUser(user.ID); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, entities.AuditAdminErase, adminId, fmt.Sprintf("user:%d", user.ID), nil)
+	return nil
+}
+
+func (s *userService) userByName(username string) (*entities.User, error) {
+	user, err := s.db.GetUserByName(username)
+	if err != nil {
+		return nil, err
+	}
+	if user == nil {
+		return nil, customErrors.ErrNotFound
+	}
+	return user, nil
+}
+
+func newUserProfileResponse(user *entities.User) *models.UserProfileResponse {
+	return &models.UserProfileResponse{
+		Username:      user.Username,
+		DisplayName:   user.DisplayName,
+		Department:    user.Department,
+		IsAdmin:       user.IsAdmin,
+		CreatedAt:     user.CreatedAt,
+		DeactivatedAt: user.DeactivatedAt,
+	}
+}
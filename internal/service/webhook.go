@@ -0,0 +1,187 @@
+package service
+
+import (
+	"avitotech/internal/audit"
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"avitotech/internal/models"
+	"avitotech/internal/webhooks"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"slices"
+)
+
+// webhookEventTypes are the events a webhook can subscribe to.
+var webhookEventTypes = []string{
+	string(events.UserRegistered),
+	string(events.CoinsSent),
+	string(events.ItemPurchased),
+	string(events.OrderStatusChanged),
+	string(events.CoinsRequested),
+}
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, adminID int, req *models.WebhookSubscriptionRequest) (*models.WebhookSubscriptionResponse, error)
+	ListSubscriptions() ([]models.WebhookSubscriptionResponse, error)
+	UpdateSubscription(ctx context.Context, adminID, id int, req *models.UpdateWebhookSubscriptionRequest) (*models.WebhookSubscriptionResponse, error)
+	DeleteSubscription(ctx context.Context, adminID, id int) error
+	ListDeliveries(subscriptionID, limit int) ([]models.WebhookDeliveryResponse, error)
+	Redeliver(ctx context.Context, adminID int, deliveryID int64) (*models.WebhookDeliveryResponse, error)
+}
+
+type webhookService struct {
+	db       database.Service
+	notifier *webhooks.Notifier
+	audit    audit.Recorder
+}
+
+func NewWebhookService(db database.Service, notifier *webhooks.Notifier, auditor audit.Recorder) *webhookService {
+	return &webhookService{
+		db:       db,
+		notifier: notifier,
+		audit:    auditor,
+	}
+}
+
+func (s *webhookService) CreateSubscription(ctx context.Context, adminID int, req *models.WebhookSubscriptionRequest) (*models.WebhookSubscriptionResponse, error) {
+	if err := validateEventTypes(req.EventTypes); err != nil {
+		return nil, err
+	}
+	secret := req.Secret
+	if secret == "" {
+		buf := make([]byte, 32)
+		if _, err := rand.Read(buf); err != nil {
+			return nil, err
+		}
+		secret = hex.EncodeToString(buf)
+	}
+	sub := &entities.WebhookSubscription{
+		URL:        req.URL,
+		EventTypes: req.EventTypes,
+		Secret:     secret,
+		CreatedBy:  adminID,
+	}
+	if err := s.db.CreateWebhookSubscription(sub); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminWebhookAdd, adminID, webhookTarget(sub.ID), audit.Details{"url": sub.URL, "eventTypes": sub.EventTypes})
+	resp := newWebhookSubscriptionResponse(sub)
+	resp.Secret = sub.Secret
+	return resp, nil
+}
+
+func (s *webhookService) ListSubscriptions() ([]models.WebhookSubscriptionResponse, error) {
+	subs, err := s.db.GetWebhookSubscriptions()
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.WebhookSubscriptionResponse, 0, len(subs))
+	for _, sub := range subs {
+		resp = append(resp, *newWebhookSubscriptionResponse(&sub))
+	}
+	return resp, nil
+}
+
+func (s *webhookService) UpdateSubscription(ctx context.Context, adminID, id int, req *models.UpdateWebhookSubscriptionRequest) (*models.WebhookSubscriptionResponse, error) {
+	sub, err := s.db.GetWebhookSubscription(id)
+	if err != nil {
+		return nil, err
+	}
+	if req.URL != nil {
+		sub.URL = *req.URL
+	}
+	if req.EventTypes != nil {
+		if err := validateEventTypes(req.EventTypes); err != nil {
+			return nil, err
+		}
+		sub.EventTypes = req.EventTypes
+	}
+	if req.Active != nil {
+		sub.Active = *req.Active
+	}
+	if err := s.db.UpdateWebhookSubscription(sub); err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminWebhookEdit, adminID, webhookTarget(id), audit.Details{"url": sub.URL, "eventTypes": sub.EventTypes, "active": sub.Active})
+	// Reload to pick up the failure counter and disabled time maintained by the database.
+	if sub, err = s.db.GetWebhookSubscription(id); err != nil {
+		return nil, err
+	}
+	return newWebhookSubscriptionResponse(sub), nil
+}
+
+func (s *webhookService) DeleteSubscription(ctx context.Context, adminID, id int) error {
+	if err := s.db.DeleteWebhookSubscription(id); err != nil {
+		return err
+	}
+	s.audit.Record(ctx, entities.AuditAdminWebhookDel, adminID, webhookTarget(id), nil)
+	return nil
+}
+
+func (s *webhookService) ListDeliveries(subscriptionID, limit int) ([]models.WebhookDeliveryResponse, error) {
+	if _, err := s.db.GetWebhookSubscription(subscriptionID); err != nil {
+		return nil, err
+	}
+	deliveries, err := s.db.GetWebhookDeliveries(subscriptionID, limit)
+	if err != nil {
+		return nil, err
+	}
+	resp := make([]models.WebhookDeliveryResponse, 0, len(deliveries))
+	for _, delivery := range deliveries {
+		resp = append(resp, *newWebhookDeliveryResponse(&delivery))
+	}
+	return resp, nil
+}
+
+func (s *webhookService) Redeliver(ctx context.Context, adminID int, deliveryID int64) (*models.WebhookDeliveryResponse, error) {
+	delivery, err := s.notifier.Redeliver(ctx, deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	s.audit.Record(ctx, entities.AuditAdminRedeliver, adminID, webhookTarget(delivery.SubscriptionID), audit.Details{"deliveryId": deliveryID, "eventId": delivery.EventID})
+	return newWebhookDeliveryResponse(delivery), nil
+}
+
+// webhookTarget names the subscription in the audit log.
+func webhookTarget(id int) string {
+	return fmt.Sprintf("webhook:%d", id)
+}
+
+func validateEventTypes(eventTypes []string) error {
+	for _, eventType := range eventTypes {
+		if !slices.Contains(webhookEventTypes, eventType) {
+			return fmt.Errorf("%w: unknown event type %q", customErrors.ErrInvalidData, eventType)
+		}
+	}
+	return nil
+}
+
+func newWebhookSubscriptionResponse(sub *entities.WebhookSubscription) *models.WebhookSubscriptionResponse {
+	return &models.WebhookSubscriptionResponse{
+		ID:                  sub.ID,
+		URL:                 sub.URL,
+		EventTypes:          sub.EventTypes,
+		Active:              sub.Active,
+		ConsecutiveFailures: sub.ConsecutiveFailures,
+		DisabledAt:          sub.DisabledAt,
+		CreatedAt:           sub.CreatedAt,
+	}
+}
+
+func newWebhookDeliveryResponse(delivery *entities.WebhookDelivery) *models.WebhookDeliveryResponse {
+	return &models.WebhookDeliveryResponse{
+		ID:             delivery.ID,
+		SubscriptionID: delivery.SubscriptionID,
+		EventID:        delivery.EventID,
+		EventType:      delivery.EventType,
+		Success:        delivery.Success,
+		StatusCode:     delivery.StatusCode,
+		Error:          delivery.Error,
+		DurationMs:     delivery.DurationMs,
+		CreatedAt:      delivery.CreatedAt,
+	}
+}
@@ -0,0 +1,145 @@
+package webhooks
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"avitotech/internal/logging"
+	"avitotech/pkg/clock"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"strconv"
+)
+
+// maxErrorBody limits how much of a failed response body is kept in the delivery log.
+const maxErrorBody = 512
+
+// Notifier delivers domain events to the subscribed webhooks.
+// It is an outbox sink: an event is retried by the outbox until every active subscription accepted it.
+type Notifier struct {
+	store       database.WebhookStore
+	client      *http.Client
+	clock       clock.Clock
+	logger      *slog.Logger
+	maxFailures int
+}
+
+func NewNotifier(store database.WebhookStore, client *http.Client, clk clock.Clock, logger *slog.Logger, maxFailures int) *Notifier {
+	return &Notifier{
+		store:       store,
+		client:      client,
+		clock:       clk,
+		logger:      logger,
+		maxFailures: maxFailures,
+	}
+}
+
+func (n *Notifier) Name() string {
+	return "webhooks"
+}
+
+// Deliver sends the event to every subscription that has not received it yet.
+func (n *Notifier) Deliver(ctx context.Context, event events.Event) error {
+	subs, err := n.store.GetPendingWebhookSubscriptions(string(event.Type), event.ID)
+	if err != nil {
+		return err
+	}
+	if len(subs) == 0 {
+		return nil
+	}
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	var errs []error
+	for _, sub := range subs {
+		delivery := &entities.WebhookDelivery{
+			SubscriptionID: sub.ID,
+			EventID:        event.ID,
+			EventType:      string(event.Type),
+			Payload:        body,
+		}
+		if err := n.send(ctx, &sub, delivery); err != nil {
+			errs = append(errs, fmt.Errorf("subscription %d: %w", sub.ID, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Redeliver sends the payload of a logged delivery again and returns the new delivery.
+// Subscriptions that were deactivated or disabled after repeated failures are not sent to.
+func (n *Notifier) Redeliver(ctx context.Context, deliveryID int64) (*entities.WebhookDelivery, error) {
+	previous, err := n.store.GetWebhookDelivery(deliveryID)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := n.store.GetWebhookSubscription(previous.SubscriptionID)
+	if err != nil {
+		return nil, err
+	}
+	if !sub.Active {
+		return nil, fmt.Errorf("%w: webhook subscription %d is inactive", customErrors.ErrInvalidData, sub.ID)
+	}
+	delivery := &entities.WebhookDelivery{
+		SubscriptionID: sub.ID,
+		EventID:        previous.EventID,
+		EventType:      previous.EventType,
+		Payload:        previous.Payload,
+	}
+	// The outcome is recorded in the delivery itself, so the send error is not returned.
+	_ = n.send(ctx, sub, delivery)
+	return delivery, nil
+}
+
+// send posts the signed payload to the subscription and records the attempt.
+func (n *Notifier) send(ctx context.Context, sub *entities.WebhookSubscription, delivery *entities.WebhookDelivery) error {
+	start := n.clock.Now()
+	statusCode, sendErr := n.post(ctx, sub, delivery)
+	delivery.DurationMs = n.clock.Now().Sub(start).Milliseconds()
+	delivery.StatusCode = statusCode
+	delivery.Success = sendErr == nil
+	if sendErr != nil {
+		delivery.Error = sendErr.Error()
+	}
+
+	disabled, err := n.store.RecordWebhookDelivery(delivery, n.maxFailures)
+	if err != nil {
+		return errors.Join(sendErr, err)
+	}
+	if disabled {
+		logging.FromContext(ctx, n.logger).Warn("Webhook subscription disabled after repeated failures", "subscription_id", sub.ID, "url", sub.URL)
+	}
+	return sendErr
+}
+
+func (n *Notifier) post(ctx context.Context, sub *entities.WebhookSubscription, delivery *entities.WebhookDelivery) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(delivery.Payload))
+	if err != nil {
+		return 0, err
+	}
+	timestamp := n.clock.Now().Unix()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderEventID, strconv.FormatInt(delivery.EventID, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(timestamp, 10))
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, timestamp, delivery.Payload))
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return resp.StatusCode, fmt.Errorf("unexpected status %d: %s", resp.StatusCode, bytes.TrimSpace(body))
+	}
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
@@ -0,0 +1,219 @@
+package webhooks
+
+import (
+	"avitotech/internal/customErrors"
+	"avitotech/internal/database"
+	"avitotech/internal/entities"
+	"avitotech/internal/events"
+	"avitotech/pkg/clock"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// fakeStore keeps subscriptions and deliveries in memory.
+type fakeStore struct {
+	database.WebhookStore
+
+	mu         sync.Mutex
+	subs       map[int]*entities.WebhookSubscription
+	deliveries []entities.WebhookDelivery
+}
+
+func newFakeStore(subs ...entities.WebhookSubscription) *fakeStore {
+	store := &fakeStore{subs: make(map[int]*entities.WebhookSubscription)}
+	for i := range subs {
+		store.subs[subs[i].ID] = &subs[i]
+	}
+	return store
+}
+
+func (f *fakeStore) GetWebhookSubscription(id int) (*entities.WebhookSubscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	sub, ok := f.subs[id]
+	if !ok {
+		return nil, customErrors.ErrNotFound
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (f *fakeStore) GetPendingWebhookSubscriptions(eventType string, eventID int64) ([]entities.WebhookSubscription, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	var pending []entities.WebhookSubscription
+	for _, sub := range f.subs {
+		if !sub.Active || !slices.Contains(sub.EventTypes, eventType) {
+			continue
+		}
+		delivered := slices.ContainsFunc(f.deliveries, func(d entities.WebhookDelivery) bool {
+			return d.SubscriptionID == sub.ID && d.EventID == eventID && d.Success
+		})
+		if !delivered {
+			pending = append(pending, *sub)
+		}
+	}
+	return pending, nil
+}
+
+func (f *fakeStore) RecordWebhookDelivery(delivery *entities.WebhookDelivery, maxFailures int) (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	delivery.ID = int64(len(f.deliveries) + 1)
+	f.deliveries = append(f.deliveries, *delivery)
+	sub := f.subs[delivery.SubscriptionID]
+	if delivery.Success {
+		sub.ConsecutiveFailures = 0
+		return false, nil
+	}
+	sub.ConsecutiveFailures++
+	if sub.Active && sub.ConsecutiveFailures >= maxFailures {
+		sub.Active = false
+		return true, nil
+	}
+	return false, nil
+}
+
+func (f *fakeStore) GetWebhookDelivery(id int64) (*entities.WebhookDelivery, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if id <= 0 || int(id) > len(f.deliveries) {
+		return nil, customErrors.ErrNotFound
+	}
+	delivery := f.deliveries[id-1]
+	return &delivery, nil
+}
+
+// receiver is an httptest webhook endpoint verifying signatures.
+type receiver struct {
+	mu       sync.Mutex
+	status   int
+	received []events.Event
+	invalid  int
+}
+
+func (r *receiver) handler(secret string) http.HandlerFunc {
+	return func(w http.ResponseWriter, req *http.Request) {
+		body, _ := io.ReadAll(req.Body)
+		timestamp, _ := strconv.ParseInt(req.Header.Get(HeaderTimestamp), 10, 64)
+
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if !Verify(secret, timestamp, body, req.Header.Get(HeaderSignature)) {
+			r.invalid++
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		var event events.Event
+		_ = json.Unmarshal(body, &event)
+		r.received = append(r.received, event)
+		w.WriteHeader(r.status)
+	}
+}
+
+func newTestEvent(t *testing.T, id int64) events.Event {
+	t.Helper()
+	payload, err := json.Marshal(events.CoinsSentPayload{FromUserID: 1, ToUserID: 2, Amount: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return events.Event{ID: id, Type: events.CoinsSent, Payload: payload}
+}
+
+func TestNotifierDeliversSignedPayload(t *testing.T) {
+	recv := &receiver{status: http.StatusOK}
+	srv := httptest.NewServer(recv.handler("topsecret-topsecret"))
+	defer srv.Close()
+
+	store := newFakeStore(
+		entities.WebhookSubscription{ID: 1, URL: srv.URL, EventTypes: []string{string(events.CoinsSent)}, Secret: "topsecret-topsecret", Active: true},
+		entities.WebhookSubscription{ID: 2, URL: srv.URL, EventTypes: []string{string(events.ItemPurchased)}, Secret: "other", Active: true},
+	)
+	notifier := NewNotifier(store, srv.Client(), clock.NewRealClock(), slog.Default(), 3)
+
+	if err := notifier.Deliver(context.Background(), newTestEvent(t, 42)); err != nil {
+		t.Fatalf("expected Deliver() to return nil, got %v", err)
+	}
+	if recv.invalid != 0 || len(recv.received) != 1 || recv.received[0].ID != 42 {
+		t.Fatalf("expected one correctly signed event 42, got %v (invalid signatures: %d)", recv.received, recv.invalid)
+	}
+	if len(store.deliveries) != 1 || !store.deliveries[0].Success || store.deliveries[0].StatusCode != http.StatusOK {
+		t.Fatalf("expected one successful delivery logged, got %+v", store.deliveries)
+	}
+
+	// A retried event is not sent again to subscriptions that already accepted it.
+	if err := notifier.Deliver(context.Background(), newTestEvent(t, 42)); err != nil {
+		t.Fatalf("expected Deliver() to return nil, got %v", err)
+	}
+	if len(recv.received) != 1 {
+		t.Fatalf("expected event not to be redelivered, got %d deliveries", len(recv.received))
+	}
+}
+
+func TestNotifierDisablesFailingSubscription(t *testing.T) {
+	recv := &receiver{status: http.StatusInternalServerError}
+	srv := httptest.NewServer(recv.handler("topsecret-topsecret"))
+	defer srv.Close()
+
+	store := newFakeStore(entities.WebhookSubscription{ID: 1, URL: srv.URL, EventTypes: []string{string(events.CoinsSent)}, Secret: "topsecret-topsecret", Active: true})
+	notifier := NewNotifier(store, srv.Client(), clock.NewRealClock(), slog.Default(), 3)
+
+	for attempt := 1; attempt <= 3; attempt++ {
+		if err := notifier.Deliver(context.Background(), newTestEvent(t, 7)); err == nil {
+			t.Fatalf("attempt %d: expected Deliver() to fail on status 500", attempt)
+		}
+	}
+	if store.subs[1].Active {
+		t.Fatal("expected subscription to be disabled after 3 consecutive failures")
+	}
+	if store.deliveries[0].StatusCode != http.StatusInternalServerError || store.deliveries[0].Error == "" {
+		t.Fatalf("expected failed delivery to log status and error, got %+v", store.deliveries[0])
+	}
+	// Disabled subscriptions no longer block the event.
+	if err := notifier.Deliver(context.Background(), newTestEvent(t, 7)); err != nil {
+		t.Fatalf("expected Deliver() to skip disabled subscription, got %v", err)
+	}
+}
+
+func TestNotifierRedeliver(t *testing.T) {
+	recv := &receiver{status: http.StatusBadGateway}
+	srv := httptest.NewServer(recv.handler("topsecret-topsecret"))
+	defer srv.Close()
+
+	store := newFakeStore(entities.WebhookSubscription{ID: 1, URL: srv.URL, EventTypes: []string{string(events.CoinsSent)}, Secret: "topsecret-topsecret", Active: true})
+	notifier := NewNotifier(store, srv.Client(), clock.NewRealClock(), slog.Default(), 10)
+
+	_ = notifier.Deliver(context.Background(), newTestEvent(t, 9))
+	recv.status = http.StatusNoContent
+
+	delivery, err := notifier.Redeliver(context.Background(), 1)
+	if err != nil {
+		t.Fatalf("expected Redeliver() to return nil, got %v", err)
+	}
+	if !delivery.Success || delivery.ID != 2 || delivery.EventID != 9 {
+		t.Fatalf("expected successful redelivery of event 9 logged as delivery 2, got %+v", delivery)
+	}
+	if len(recv.received) != 2 || recv.invalid != 0 {
+		t.Fatalf("expected the payload to be sent twice with valid signatures, got %d (invalid %d)", len(recv.received), recv.invalid)
+	}
+	if _, err := notifier.Redeliver(context.Background(), 99); err == nil {
+		t.Fatal("expected Redeliver() of unknown delivery to fail")
+	}
+
+	store.subs[1].Active = false
+	if _, err := notifier.Redeliver(context.Background(), 1); !errors.Is(err, customErrors.ErrInvalidData) {
+		t.Fatalf("expected Redeliver() to an inactive subscription to return ErrInvalidData, got %v", err)
+	}
+	if len(recv.received) != 2 {
+		t.Fatalf("expected nothing to be sent to an inactive subscription, got %d deliveries", len(recv.received))
+	}
+}
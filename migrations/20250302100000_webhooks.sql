@@ -0,0 +1,40 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN is_admin BOOLEAN NOT NULL DEFAULT FALSE;
+
+CREATE TABLE webhook_subscriptions (
+                                       id SERIAL PRIMARY KEY,
+                                       url TEXT NOT NULL,
+                                       event_types JSONB NOT NULL,
+                                       secret VARCHAR(255) NOT NULL,
+                                       active BOOLEAN NOT NULL DEFAULT TRUE,
+                                       consecutive_failures INTEGER NOT NULL DEFAULT 0,
+                                       disabled_at TIMESTAMP,
+                                       created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+                                       created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                       updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE TABLE webhook_deliveries (
+                                    id BIGSERIAL PRIMARY KEY,
+                                    subscription_id INTEGER NOT NULL REFERENCES webhook_subscriptions(id) ON DELETE CASCADE,
+                                    event_id BIGINT NOT NULL,
+                                    event_type VARCHAR(100) NOT NULL,
+                                    payload JSONB NOT NULL,
+                                    success BOOLEAN NOT NULL,
+                                    status_code INTEGER,
+                                    error TEXT,
+                                    duration_ms INTEGER NOT NULL DEFAULT 0,
+                                    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_webhook_deliveries_subscription ON webhook_deliveries(subscription_id, id);
+CREATE INDEX idx_webhook_deliveries_event ON webhook_deliveries(event_id, subscription_id) WHERE success;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE webhook_deliveries;
+DROP TABLE webhook_subscriptions;
+ALTER TABLE users DROP COLUMN is_admin;
+-- +goose StatementEnd
@@ -0,0 +1,21 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE orders (
+                        id SERIAL PRIMARY KEY,
+                        user_id INTEGER REFERENCES users(id) ON DELETE SET NULL,
+                        item_type VARCHAR(255) NOT NULL,
+                        quantity INTEGER NOT NULL DEFAULT 1,
+                        price INTEGER NOT NULL,
+                        status VARCHAR(50) NOT NULL DEFAULT 'placed',
+                        created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                        updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_orders_user_id ON orders(user_id, id);
+CREATE INDEX idx_orders_status ON orders(status, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE orders;
+-- +goose StatementEnd
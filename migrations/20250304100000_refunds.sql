@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE coin_transactions ADD COLUMN order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE coin_transactions DROP COLUMN order_id;
+-- +goose StatementEnd
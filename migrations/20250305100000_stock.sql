@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NULL stock means the item is unlimited.
+ALTER TABLE shop ADD COLUMN stock INTEGER CHECK (stock >= 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE shop DROP COLUMN stock;
+-- +goose StatementEnd
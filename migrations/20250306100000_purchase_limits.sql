@@ -0,0 +1,10 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NULL means a user may buy any number of units.
+ALTER TABLE shop ADD COLUMN max_per_user INTEGER CHECK (max_per_user > 0);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE shop DROP COLUMN max_per_user;
+-- +goose StatementEnd
@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE coin_transactions ADD COLUMN message VARCHAR(255) NOT NULL DEFAULT '';
+
+CREATE TABLE coin_requests (
+                               id SERIAL PRIMARY KEY,
+                               requester_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               payer_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                               amount INTEGER NOT NULL,
+                               message VARCHAR(255) NOT NULL DEFAULT '',
+                               status VARCHAR(50) NOT NULL DEFAULT 'pending',
+                               created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                               updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_coin_requests_requester_id ON coin_requests(requester_id, id);
+CREATE INDEX idx_coin_requests_payer_id ON coin_requests(payer_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE coin_requests;
+ALTER TABLE coin_transactions DROP COLUMN message;
+-- +goose StatementEnd
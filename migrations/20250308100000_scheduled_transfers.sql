@@ -0,0 +1,38 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE scheduled_transfers (
+                                     id SERIAL PRIMARY KEY,
+                                     from_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     to_user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+                                     amount INTEGER NOT NULL,
+                                     message VARCHAR(255) NOT NULL DEFAULT '',
+                                     -- Exactly one of run_at (one-off) and cron (recurring) is set.
+                                     run_at TIMESTAMP,
+                                     cron VARCHAR(100) NOT NULL DEFAULT '',
+                                     next_run_at TIMESTAMP,
+                                     active BOOLEAN NOT NULL DEFAULT TRUE,
+                                     consecutive_failures INTEGER NOT NULL DEFAULT 0,
+                                     created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+                                     updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_scheduled_transfers_due ON scheduled_transfers(next_run_at) WHERE active;
+CREATE INDEX idx_scheduled_transfers_from_user_id ON scheduled_transfers(from_user_id, id);
+
+CREATE TABLE scheduled_transfer_runs (
+                                         id BIGSERIAL PRIMARY KEY,
+                                         scheduled_transfer_id INTEGER NOT NULL REFERENCES scheduled_transfers(id) ON DELETE CASCADE,
+                                         scheduled_for TIMESTAMP NOT NULL,
+                                         executed_at TIMESTAMP NOT NULL,
+                                         success BOOLEAN NOT NULL,
+                                         error TEXT NOT NULL DEFAULT ''
+);
+
+CREATE INDEX idx_scheduled_transfer_runs_transfer_id ON scheduled_transfer_runs(scheduled_transfer_id, id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE scheduled_transfer_runs;
+DROP TABLE scheduled_transfers;
+-- +goose StatementEnd
@@ -0,0 +1,9 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE coin_transactions ADD COLUMN actor_id INTEGER REFERENCES users(id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE coin_transactions DROP COLUMN actor_id;
+-- +goose StatementEnd
@@ -0,0 +1,37 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE audit_log (
+                           id BIGSERIAL PRIMARY KEY,
+                           action VARCHAR(100) NOT NULL,
+                           actor_id INTEGER,
+                           target VARCHAR(255) NOT NULL DEFAULT '',
+                           details JSON NOT NULL DEFAULT '{}',
+                           ip VARCHAR(64) NOT NULL DEFAULT '',
+                           user_agent VARCHAR(512) NOT NULL DEFAULT '',
+                           request_id VARCHAR(128) NOT NULL DEFAULT '',
+                           created_at TIMESTAMPTZ NOT NULL,
+                           prev_hash CHAR(64) NOT NULL,
+                           hash CHAR(64) NOT NULL UNIQUE
+);
+
+CREATE INDEX idx_audit_log_actor_id ON audit_log(actor_id, id);
+CREATE INDEX idx_audit_log_action ON audit_log(action, id);
+CREATE INDEX idx_audit_log_created_at ON audit_log(created_at);
+
+CREATE FUNCTION audit_log_append_only() RETURNS trigger AS $$
+BEGIN
+    RAISE EXCEPTION 'audit_log is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+CREATE TRIGGER audit_log_no_update BEFORE UPDATE OR DELETE ON audit_log
+    FOR EACH ROW EXECUTE FUNCTION audit_log_append_only();
+CREATE TRIGGER audit_log_no_truncate BEFORE TRUNCATE ON audit_log
+    FOR EACH STATEMENT EXECUTE FUNCTION audit_log_append_only();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE audit_log;
+DROP FUNCTION audit_log_append_only();
+-- +goose StatementEnd
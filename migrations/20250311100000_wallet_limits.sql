@@ -0,0 +1,11 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_coin_transactions_from_user_created ON coin_transactions(from_user_id, created_at);
+CREATE INDEX idx_orders_user_created ON orders(user_id, created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_orders_user_created;
+DROP INDEX idx_coin_transactions_from_user_created;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+-- NOT VALID enforces the checks on every new and updated row without failing on history written before
+-- amounts were validated; run VALIDATE CONSTRAINT once such rows have been reviewed.
+ALTER TABLE coins ADD CONSTRAINT coins_amount_non_negative CHECK (amount >= 0) NOT VALID;
+ALTER TABLE coin_transactions ADD CONSTRAINT coin_transactions_amount_non_negative CHECK (amount >= 0) NOT VALID;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE coin_transactions DROP CONSTRAINT coin_transactions_amount_non_negative;
+ALTER TABLE coins DROP CONSTRAINT coins_amount_non_negative;
+-- +goose StatementEnd
@@ -0,0 +1,13 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE INDEX idx_coin_transactions_send_created ON coin_transactions(created_at) WHERE transaction_type = 'send';
+CREATE INDEX idx_coin_transactions_to_user_created ON coin_transactions(to_user_id, created_at);
+CREATE INDEX idx_orders_created ON orders(created_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_orders_created;
+DROP INDEX idx_coin_transactions_to_user_created;
+DROP INDEX idx_coin_transactions_send_created;
+-- +goose StatementEnd
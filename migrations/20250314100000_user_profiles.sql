@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE users ADD COLUMN display_name VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE users ADD COLUMN department VARCHAR(100) NOT NULL DEFAULT '';
+
+-- Serves both the prefix (ILIKE 'q%') and the fuzzy (%) match of the directory search.
+CREATE INDEX idx_users_username_trgm ON users USING GIN (username gin_trgm_ops);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_users_username_trgm;
+ALTER TABLE users DROP COLUMN department;
+ALTER TABLE users DROP COLUMN display_name;
+-- +goose StatementEnd
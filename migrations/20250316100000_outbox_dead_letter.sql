@@ -0,0 +1,17 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE outbox_events ADD COLUMN delivered_sinks JSONB NOT NULL DEFAULT '[]';
+ALTER TABLE outbox_events ADD COLUMN dead_at TIMESTAMP;
+
+DROP INDEX idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE delivered_at IS NULL AND dead_at IS NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP INDEX idx_outbox_events_pending;
+CREATE INDEX idx_outbox_events_pending ON outbox_events(next_attempt_at) WHERE delivered_at IS NULL;
+
+ALTER TABLE outbox_events DROP COLUMN dead_at;
+ALTER TABLE outbox_events DROP COLUMN delivered_sinks;
+-- +goose StatementEnd
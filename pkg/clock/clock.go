@@ -0,0 +1,20 @@
+package clock
+
+import "time"
+
+// Clock provides the current time.
+type Clock interface {
+	// Now returns the current time.
+	Now() time.Time
+}
+
+type realClock struct{}
+
+// NewRealClock returns a Clock backed by time.Now.
+func NewRealClock() Clock {
+	return realClock{}
+}
+
+func (realClock) Now() time.Time {
+	return time.Now()
+}
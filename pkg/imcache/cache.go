@@ -23,12 +23,15 @@ type InMemoryCache struct {
 	items map[string]CacheItem
 	mu    sync.RWMutex
 	ttl   time.Duration
+	done  chan struct{}
+	once  sync.Once
 }
 
 func NewInMemoryCache(defaultTTL time.Duration) *InMemoryCache {
 	cache := &InMemoryCache{
 		items: make(map[string]CacheItem),
 		ttl:   defaultTTL,
+		done:  make(chan struct{}),
 	}
 	go cache.startCleanupJob()
 	return cache
@@ -60,10 +63,23 @@ func (c *InMemoryCache) Delete(key string) {
 	delete(c.items, key)
 }
 
+// Close stops the background cleanup job.
+func (c *InMemoryCache) Close() {
+	c.once.Do(func() {
+		close(c.done)
+	})
+}
+
 func (c *InMemoryCache) startCleanupJob() {
 	ticker := time.NewTicker(time.Minute)
-	for range ticker.C {
-		c.cleanup()
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			c.cleanup()
+		case <-c.done:
+			return
+		}
 	}
 }
 